@@ -143,3 +143,81 @@ func Apply[T PrimitiveTypes](operator Operator, tensors ...*Tensor[T]) (*Tensor[
 
 	return output, nil
 }
+
+// runSession finalizes the graph built under root scope and runs it in a
+// new session feeding feeds and fetching fetches. This is useful for
+// graphs that do not fit an Operator, such as those with no input
+// tensors or with several outputs
+func runSession(root *op.Scope, feeds map[tf.Output]*tf.Tensor, fetches ...tf.Output) ([]*tf.Tensor, error) {
+	graph, err := root.Finalize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to import graph: %w", err)
+	}
+
+	sess, err := tf.NewSession(
+		graph,
+		&tf.SessionOptions{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new tf session: %w", err)
+	}
+
+	defer func(sess *tf.Session) {
+		err := sess.Close()
+		if err != nil {
+			panic(err)
+		}
+	}(sess)
+
+	out, err := sess.Run(feeds, fetches, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run tf session: %w", err)
+	}
+
+	if len(out) != len(fetches) {
+		return nil, fmt.Errorf("expected session run output to have length %d, got %d", len(fetches), len(out))
+	}
+
+	return out, nil
+}
+
+// runOutput runs graph built under root scope via runSession and
+// unmarshals its single output into a tensor of data type T
+func runOutput[T PrimitiveTypes](root *op.Scope, feeds map[tf.Output]*tf.Tensor, output tf.Output) (*Tensor[T], error) {
+	out, err := runSession(root, feeds, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromTfTensor[T](out[0])
+}
+
+// fromTfTensor unmarshals tf tensor into a new tensor. Unlike Unmarshal,
+// a scalar tf tensor, such as a reduction over all dimensions, is
+// accepted and represented as a vector of length 1
+func fromTfTensor[T PrimitiveTypes](tfTensor *tf.Tensor) (*Tensor[T], error) {
+	if len(tfTensor.Shape()) == 0 {
+		if err := tfTensor.Reshape([]int64{1}); err != nil {
+			return nil, fmt.Errorf("failed to reshape scalar output: %w", err)
+		}
+	}
+
+	tensor := &Tensor[T]{}
+	if err := tensor.Unmarshal(tfTensor); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal output: %w", err)
+	}
+
+	return tensor, nil
+}
+
+// placeholder adds a placeholder to the graph under namespace name
+// matching data type and shape of input tf tensor
+func placeholder(scope *op.Scope, name string, tfTensor *tf.Tensor) tf.Output {
+	return op.Placeholder(
+		scope.SubScope(name),
+		tfTensor.DataType(),
+		op.PlaceholderShape(
+			tf.MakeShape(tfTensor.Shape()...),
+		),
+	)
+}
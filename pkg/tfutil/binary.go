@@ -0,0 +1,254 @@
+package tfutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// binary format of a tensor record consists of a header holding magic,
+// version, data type, rank, dimensions and length of contents, followed
+// by contents and a crc32 (castagnoli) checksum of everything before it.
+// All integers are little endian. Numeric and bool contents are values in
+// row major order, strings are each prefixed by their uvarint length
+const (
+	binaryMagic   = "TFUT"
+	binaryVersion = 1
+)
+
+var binaryCrcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteTo writes tensor to w as a single record of a compact binary
+// format with a checksum. Records of several tensors can be written to
+// the same writer one after another and read back in the same order
+// via ReadFrom
+func (tensor *Tensor[T]) WriteTo(w io.Writer) (int64, error) {
+	return writeBinary(w, tensor.value, tensor.shape)
+}
+
+// ReadFrom reads a single record written by WriteTo from r, verifying its
+// checksum. Unlike ReadFrom of io.ReaderFrom, it stops at the end of the
+// record so that subsequent records can be read from r. Data type of the
+// record needs to match T. io.EOF is returned if r has no more records. A
+// scalar record is read as a vector of length 1
+func (tensor *Tensor[T]) ReadFrom(r io.Reader) (int64, error) {
+	values, shape, n, err := readBinary[T](r)
+	if err != nil {
+		return n, err
+	}
+
+	if len(shape) == 0 {
+		shape = []int{1}
+	}
+
+	tensor.value = values
+	tensor.shape = shape
+
+	return n, nil
+}
+
+// WriteTo writes scalar to w as a record of scalar shape. See
+// Tensor.WriteTo for details
+func (g *Scalar[T]) WriteTo(w io.Writer) (int64, error) {
+	return writeBinary(w, []T{g.value}, nil)
+}
+
+// ReadFrom reads a single record holding one element from r. See
+// Tensor.ReadFrom for details
+func (g *Scalar[T]) ReadFrom(r io.Reader) (int64, error) {
+	values, shape, n, err := readBinary[T](r)
+	if err != nil {
+		return n, err
+	}
+
+	if len(values) != 1 {
+		return n, fmt.Errorf("scalar needs record with one element, got shape %v", shape)
+	}
+
+	g.value = values[0]
+	return n, nil
+}
+
+// writeBinary writes a record of values of given shape to w
+func writeBinary[T PrimitiveTypes](w io.Writer, values []T, shape []int) (int64, error) {
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return 0, err
+	}
+
+	contents := &bytes.Buffer{}
+	if strs, ok := any(values).([]string); ok {
+		for _, s := range strs {
+			contents.Write(binary.AppendUvarint(nil, uint64(len(s))))
+			contents.WriteString(s)
+		}
+	} else if err := binary.Write(contents, binary.LittleEndian, values); err != nil {
+		return 0, fmt.Errorf("failed to serialize tensor contents: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(binaryMagic)
+	buf.WriteByte(binaryVersion)
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(dataType)))
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(shape))))
+	for _, dim := range shape {
+		buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(dim)))
+	}
+	buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(contents.Len())))
+	buf.Write(contents.Bytes())
+	buf.Write(binary.LittleEndian.AppendUint32(nil, crc32.Checksum(buf.Bytes(), binaryCrcTable)))
+
+	n, err := w.Write(buf.Bytes())
+	if err != nil {
+		return int64(n), fmt.Errorf("failed to write tensor record: %w", err)
+	}
+
+	return int64(n), nil
+}
+
+// recordReader reads exactly requested number of bytes from r while
+// counting them and updating checksum
+type recordReader struct {
+	r    io.Reader
+	hash hash.Hash32
+	n    int64
+}
+
+func (rr *recordReader) read(size uint64) ([]byte, error) {
+	// contents are copied rather than preallocated so that a corrupt
+	// length can't trigger a huge allocation
+	buf := &bytes.Buffer{}
+	n, err := io.CopyN(buf, rr.r, int64(size))
+	rr.n += n
+	if err != nil {
+		if errors.Is(err, io.EOF) && rr.n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	_, _ = rr.hash.Write(buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+// readBinary reads a record of data type T from r returning its values,
+// shape and number of bytes read
+func readBinary[T PrimitiveTypes](r io.Reader) ([]T, []int, int64, error) {
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	rr := &recordReader{r: r, hash: crc32.New(binaryCrcTable)}
+
+	header, err := rr.read(uint64(len(binaryMagic)) + 9)
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil, 0, io.EOF
+		}
+		return nil, nil, rr.n, fmt.Errorf("failed to read tensor record header: %w", err)
+	}
+
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return nil, nil, rr.n, fmt.Errorf("invalid tensor record magic %q", header[:len(binaryMagic)])
+	}
+
+	header = header[len(binaryMagic):]
+	if header[0] != binaryVersion {
+		return nil, nil, rr.n, fmt.Errorf("unsupported tensor record version %d", header[0])
+	}
+
+	if recordType := binary.LittleEndian.Uint32(header[1:]); recordType != uint32(dataType) {
+		return nil, nil, rr.n, fmt.Errorf("tensor record data type %d does not match data type %T", recordType, *new(T))
+	}
+
+	rank := binary.LittleEndian.Uint32(header[5:])
+	if rank > 254 {
+		return nil, nil, rr.n, fmt.Errorf("invalid tensor record rank %d", rank)
+	}
+
+	dims, err := rr.read(8*uint64(rank) + 8)
+	if err != nil {
+		return nil, nil, rr.n, fmt.Errorf("failed to read tensor record shape: %w", err)
+	}
+
+	// number of values is checked for overflow while multiplying
+	// dimensions, since shape comes from a possibly corrupt header
+	shape := make([]int, rank)
+	numValues := 1
+	for i := range shape {
+		dim := binary.LittleEndian.Uint64(dims[8*i:])
+		if dim == 0 || dim > uint64(math.MaxInt/numValues) {
+			return nil, nil, rr.n, fmt.Errorf("invalid tensor record dimension %d of size %d", i, dim)
+		}
+		shape[i] = int(dim)
+		numValues *= shape[i]
+	}
+
+	size := binary.LittleEndian.Uint64(dims[8*rank:])
+	_, isString := any(*new(T)).(string)
+	if !isString {
+		elementSize := uint64(binary.Size(*new(T)))
+		if uint64(numValues) > math.MaxInt64/elementSize {
+			return nil, nil, rr.n, fmt.Errorf("tensor record shape %v is too large", shape)
+		}
+
+		if expected := elementSize * uint64(numValues); size != expected {
+			return nil, nil, rr.n, fmt.Errorf("expected tensor record contents of %d bytes for shape %v, got %d", expected, shape, size)
+		}
+	}
+
+	if size > math.MaxInt64 {
+		return nil, nil, rr.n, fmt.Errorf("invalid tensor record contents length %d", size)
+	}
+
+	contents, err := rr.read(size)
+	if err != nil {
+		return nil, nil, rr.n, fmt.Errorf("failed to read tensor record contents: %w", err)
+	}
+
+	checksum := rr.hash.Sum32()
+	footer, err := rr.read(4)
+	if err != nil {
+		return nil, nil, rr.n, fmt.Errorf("failed to read tensor record checksum: %w", err)
+	}
+
+	if binary.LittleEndian.Uint32(footer) != checksum {
+		return nil, nil, rr.n, fmt.Errorf("tensor record checksum mismatch")
+	}
+
+	if isString {
+		// each string takes at least one byte for its length
+		if numValues > len(contents) {
+			return nil, nil, rr.n, fmt.Errorf("tensor record of %d strings has only %d bytes of contents", numValues, len(contents))
+		}
+
+		strs := make([]string, numValues)
+		for i := range strs {
+			length, k := binary.Uvarint(contents)
+			if k <= 0 || length > uint64(len(contents)-k) {
+				return nil, nil, rr.n, fmt.Errorf("invalid tensor record string %d", i)
+			}
+			strs[i] = string(contents[k : k+int(length)])
+			contents = contents[k+int(length):]
+		}
+
+		if len(contents) != 0 {
+			return nil, nil, rr.n, fmt.Errorf("tensor record has %d trailing bytes of contents", len(contents))
+		}
+
+		return any(strs).([]T), shape, rr.n, nil
+	}
+
+	values := make([]T, numValues)
+	if err := binary.Read(bytes.NewReader(contents), binary.LittleEndian, values); err != nil {
+		return nil, nil, rr.n, fmt.Errorf("failed to deserialize tensor contents: %w", err)
+	}
+
+	return values, shape, rr.n, nil
+}
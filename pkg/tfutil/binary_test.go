@@ -0,0 +1,141 @@
+package tfutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+func TestBinaryStream(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3, 4, 5, 6}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float64{7, 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	for _, tensor := range []*Tensor[float64]{x, y} {
+		if _, err := tensor.WriteTo(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, expected := range []*Tensor[float64]{x, y} {
+		output := &Tensor[float64]{}
+		if _, err := output.ReadFrom(buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if !equal(output.value, expected.value) || !equal(output.shape, expected.shape) {
+			t.Fatal("binary round trip does not match input")
+		}
+	}
+
+	if _, err := (&Tensor[float64]{}).ReadFrom(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF at end of stream, got %v", err)
+	}
+
+	strs, err := NewTensor([]string{"a", "", "héllo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := strs.WriteTo(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strsOut := &Tensor[string]{}
+	if m, err := strsOut.ReadFrom(buf); err != nil || m != n {
+		t.Fatalf("expected %d bytes read, got %d: %v", n, m, err)
+	}
+
+	if !equal(strsOut.value, strs.value) {
+		t.Fatalf("binary round trip of strings does not match input, got %q", strsOut.value)
+	}
+
+	scalar := NewScalar(complex64(1 - 2i))
+	if _, err := scalar.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	scalarOut := &Scalar[complex64]{}
+	if _, err := scalarOut.ReadFrom(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if scalarOut.Value() != scalar.Value() {
+		t.Fatalf("expected %v, got %v", scalar.Value(), scalarOut.Value())
+	}
+}
+
+func TestBinaryCorrupt(t *testing.T) {
+	x, err := NewTensor([]int32{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := x.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	record := buf.Bytes()
+
+	corrupt := bytes.Clone(record)
+	corrupt[len(corrupt)-6]++
+	if _, err := (&Tensor[int32]{}).ReadFrom(bytes.NewReader(corrupt)); err == nil {
+		t.Fatal("expected checksum error")
+	}
+
+	if _, err := (&Tensor[int32]{}).ReadFrom(bytes.NewReader(record[:len(record)-1])); err == nil || err == io.EOF {
+		t.Fatalf("expected error for truncated record, got %v", err)
+	}
+
+	if _, err := (&Tensor[int64]{}).ReadFrom(bytes.NewReader(record)); err == nil {
+		t.Fatal("expected error for mismatched data type")
+	}
+
+	// shapes whose number of elements overflows are rejected even if
+	// contents length and checksum are consistent with the overflow
+	int32Type, err := dataTypeOf[int32]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overflow := newRecord(uint32(int32Type), []uint64{1 << 32, 1 << 32}, nil)
+	if _, err := (&Tensor[int32]{}).ReadFrom(bytes.NewReader(overflow)); err == nil {
+		t.Fatal("expected error for overflowing shape")
+	}
+
+	// string count beyond contents is rejected before allocation
+	stringType, err := dataTypeOf[string]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	huge := newRecord(uint32(stringType), []uint64{1 << 62}, []byte{1, 'a'})
+	if _, err := (&Tensor[string]{}).ReadFrom(bytes.NewReader(huge)); err == nil {
+		t.Fatal("expected error for string count beyond contents")
+	}
+}
+
+// newRecord builds a binary tensor record with a valid checksum
+func newRecord(dataType uint32, shape []uint64, contents []byte) []byte {
+	record := []byte(binaryMagic)
+	record = append(record, binaryVersion)
+	record = binary.LittleEndian.AppendUint32(record, dataType)
+	record = binary.LittleEndian.AppendUint32(record, uint32(len(shape)))
+	for _, dim := range shape {
+		record = binary.LittleEndian.AppendUint64(record, dim)
+	}
+	record = binary.LittleEndian.AppendUint64(record, uint64(len(contents)))
+	record = append(record, contents...)
+	return binary.LittleEndian.AppendUint32(record, crc32.Checksum(record, binaryCrcTable))
+}
@@ -0,0 +1,476 @@
+package tfutil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+	"golang.org/x/exp/constraints"
+)
+
+// RoundingMode defines how fractional values are handled when
+// casting floating point values to integers
+type RoundingMode int
+
+const (
+	// RoundTruncate discards fractional part, i.e., rounds toward zero
+	RoundTruncate RoundingMode = iota
+	// RoundHalfEven rounds to the nearest integer with ties to even
+	RoundHalfEven
+	// RoundFloor rounds toward negative infinity
+	RoundFloor
+	// RoundCeil rounds toward positive infinity
+	RoundCeil
+)
+
+// CastOption configures conversion performed by Cast
+type CastOption func(*castOptions)
+
+type castOptions struct {
+	rounding   RoundingMode
+	saturate   bool
+	precision  int
+	width      int
+	fill       string
+	scientific bool
+	shortest   bool
+}
+
+// CastRounding sets rounding mode for float to integer conversion.
+// Default is RoundTruncate
+func CastRounding(mode RoundingMode) CastOption {
+	return func(o *castOptions) {
+		o.rounding = mode
+	}
+}
+
+// CastSaturate sets saturation for conversion to integers, in which
+// values out of range of the output data type are clamped to its
+// minimum or maximum value instead of being reported as errors
+func CastSaturate(value bool) CastOption {
+	return func(o *castOptions) {
+		o.saturate = value
+	}
+}
+
+// CastPrecision sets post-decimal precision for formatting floating
+// point values as strings. Negative value implies default precision
+func CastPrecision(value int) CastOption {
+	return func(o *castOptions) {
+		o.precision = value
+	}
+}
+
+// CastWidth sets minimum width of numbers formatted as strings, which
+// are padded using fill. Negative value implies no padding
+func CastWidth(value int) CastOption {
+	return func(o *castOptions) {
+		o.width = value
+	}
+}
+
+// CastFill sets padding character used with CastWidth. Default is
+// space and it can be set to "0" for zero padding
+func CastFill(value string) CastOption {
+	return func(o *castOptions) {
+		o.fill = value
+	}
+}
+
+// CastScientific sets scientific notation for formatting floating
+// point values as strings
+func CastScientific(value bool) CastOption {
+	return func(o *castOptions) {
+		o.scientific = value
+	}
+}
+
+// CastShortest sets the shortest representation, either scientific
+// or standard, for formatting floating point values as strings
+func CastShortest(value bool) CastOption {
+	return func(o *castOptions) {
+		o.shortest = value
+	}
+}
+
+func newCastOptions(options []CastOption) *castOptions {
+	o := &castOptions{
+		rounding:  RoundTruncate,
+		precision: -1,
+		width:     -1,
+	}
+
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// Cast casts input tensor of data type T to a new tensor of data type S.
+// Strings are parsed to floats via tensorflow StringToNumber op and to
+// integers via strconv, which saturates as per CastSaturate. Numbers are
+// formatted as strings via AsString op, which is
+// configurable via options such as CastPrecision and CastWidth.
+// Strings are parsed to bool values via strconv.ParseBool.
+// Float to integer conversion truncates by default, which can be changed
+// via CastRounding. Values that can't be converted, such as NaN or out
+// of range values of float or integer inputs when CastSaturate is not
+// set, are reported as an error pointing to the index of the offending
+// element
+func Cast[S, T PrimitiveTypes](input *Tensor[T], options ...CastOption) (*Tensor[S], error) {
+	o := newCastOptions(options)
+
+	if o.scientific && o.shortest {
+		return nil, fmt.Errorf("scientific and shortest notation can't be set together")
+	}
+
+	// string to bool conversion has no tensorflow kernel
+	if values, ok := any(input.value).([]string); ok {
+		if _, ok := any(*new(S)).(bool); ok {
+			output := make([]bool, len(values))
+			for i, v := range values {
+				b, err := strconv.ParseBool(strings.TrimSpace(v))
+				if err != nil {
+					return nil, fmt.Errorf("failed to convert element %d (%q) to bool: %w", i, v, err)
+				}
+				output[i] = b
+			}
+
+			return NewTensor(any(output).([]S), clone(input.shape)...)
+		}
+
+		// string to integer conversion is done in go, since parsing in
+		// tensorflow goes via int64, which can neither saturate nor hold
+		// all uint64 values
+		if _, _, isInteger := integerType[S](); isInteger {
+			output := make([]S, len(values))
+			for i, v := range values {
+				n, err := parseInteger[S](strings.TrimSpace(v), o.saturate)
+				if err != nil {
+					return nil, fmt.Errorf("failed to convert element %d (%q) to %T: %w", i, v, *new(S), err)
+				}
+				output[i] = n
+			}
+
+			return NewTensor(output, clone(input.shape)...)
+		}
+	}
+
+	if _, ok := any(*new(S)).(string); ok {
+		if err := checkFormat[T](o); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateCast[S](input.value, o); err != nil {
+		return nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to form tensor for source input: %w", err)
+	}
+
+	dataType, err := dataTypeOf[S]()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get output data type: %w", err)
+	}
+
+	root := op.NewScope()
+	X := op.Placeholder(
+		root.SubScope("X"),
+		x.DataType(),
+		op.PlaceholderShape(
+			tf.MakeShape(castToInt64(input.shape)...),
+		),
+	)
+
+	Output := castOutput[S, T](root, X, dataType, o)
+
+	return runOutput[S](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// castOutput adds operations to the graph that convert x of data type T
+// to data type S
+func castOutput[S, T PrimitiveTypes](scope *op.Scope, x tf.Output, dataType tf.DataType, o *castOptions) tf.Output {
+	bits, signed, isInteger := integerType[S]()
+
+	_, isString := any(*new(S)).(string)
+	if isString {
+		if _, ok := any(*new(T)).(string); ok {
+			return op.Identity(scope, x)
+		}
+
+		return op.AsString(
+			scope,
+			x,
+			op.AsStringPrecision(int64(o.precision)),
+			op.AsStringWidth(int64(o.width)),
+			op.AsStringFill(o.fill),
+			op.AsStringScientific(o.scientific),
+			op.AsStringShortest(o.shortest),
+		)
+	}
+
+	switch any(*new(T)).(type) {
+	case string:
+		// strings are parsed as float64 followed by a numeric cast,
+		// whereas integer outputs are parsed in go by Cast
+		return op.Cast(
+			scope,
+			op.StringToNumber(scope, x, op.StringToNumberOutType(tf.Double)),
+			dataType,
+		)
+	case float32, float64:
+		if !isInteger {
+			return op.Cast(scope, x, dataType)
+		}
+
+		switch o.rounding {
+		case RoundHalfEven:
+			x = op.Round(scope, x)
+		case RoundFloor:
+			x = op.Floor(scope, x)
+		case RoundCeil:
+			x = op.Ceil(scope, x)
+		}
+
+		if o.saturate {
+			// bounds are representable in the source float type,
+			// so clipping can't push values out of range again
+			mantissa := 53
+			if _, ok := any(*new(T)).(float32); ok {
+				mantissa = 24
+			}
+
+			lo, hi := integerRange(bits, signed, mantissa)
+			x = op.ClipByValue(
+				scope,
+				x,
+				op.Cast(scope.SubScope("min"), op.Const(scope.SubScope("min"), lo), x.DataType()),
+				op.Cast(scope.SubScope("max"), op.Const(scope.SubScope("max"), hi), x.DataType()),
+			)
+		}
+
+		return op.Cast(scope, x, dataType)
+	default:
+		sourceBits, sourceSigned, isSourceInteger := integerType[T]()
+		if !isInteger || !isSourceInteger || !o.saturate {
+			return op.Cast(scope, x, dataType)
+		}
+
+		// bounds are limited to the source range, so that they are
+		// representable in the source data type
+		lo, hi := integerLimits(bits, signed)
+		sourceLo, sourceHi := integerLimits(sourceBits, sourceSigned)
+		lo, hi = max(lo, sourceLo), min(hi, sourceHi)
+		if lo > sourceLo || hi < sourceHi {
+			x = op.ClipByValue(
+				scope,
+				x,
+				op.Cast(scope.SubScope("min"), op.Const(scope.SubScope("min"), lo), x.DataType()),
+				op.Cast(scope.SubScope("max"), op.Const(scope.SubScope("max"), hi), x.DataType()),
+			)
+		}
+
+		return op.Cast(scope, x, dataType)
+	}
+}
+
+// validateCast checks, prior to graph construction, that each element
+// of values can be converted to data type S and reports the index of
+// the first element that can't be converted
+func validateCast[S, T PrimitiveTypes](values []T, o *castOptions) error {
+	bits, signed, isInteger := integerType[S]()
+
+	switch values := any(values).(type) {
+	case []string:
+		if _, ok := any(*new(S)).(string); ok {
+			return nil
+		}
+
+		for i, v := range values {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+				return fmt.Errorf("failed to convert element %d (%q) to %T: %w", i, v, *new(S), err)
+			}
+		}
+	case []float32:
+		if isInteger {
+			for i, v := range values {
+				if err := validateFloatToInteger(float64(v), bits, signed, o); err != nil {
+					return fmt.Errorf("failed to convert element %d (%v) to %T: %w", i, v, *new(S), err)
+				}
+			}
+		}
+	case []float64:
+		if isInteger {
+			for i, v := range values {
+				if err := validateFloatToInteger(v, bits, signed, o); err != nil {
+					return fmt.Errorf("failed to convert element %d (%v) to %T: %w", i, v, *new(S), err)
+				}
+			}
+		}
+	case []int8:
+		return validateIntegerToInteger[S](values, o)
+	case []int16:
+		return validateIntegerToInteger[S](values, o)
+	case []int32:
+		return validateIntegerToInteger[S](values, o)
+	case []int64:
+		return validateIntegerToInteger[S](values, o)
+	case []uint8:
+		return validateIntegerToInteger[S](values, o)
+	case []uint16:
+		return validateIntegerToInteger[S](values, o)
+	case []uint32:
+		return validateIntegerToInteger[S](values, o)
+	case []uint64:
+		return validateIntegerToInteger[S](values, o)
+	}
+
+	return nil
+}
+
+// validateIntegerToInteger checks that each of values fits integer data
+// type S unless saturation is set and reports the index of the first
+// element that does not fit
+func validateIntegerToInteger[S PrimitiveTypes, T constraints.Integer](values []T, o *castOptions) error {
+	bits, signed, isInteger := integerType[S]()
+	if !isInteger || o.saturate {
+		return nil
+	}
+
+	lo, hi := integerLimits(bits, signed)
+	for i, v := range values {
+		// negative values only occur for signed T, which int64 holds
+		if (v < 0 && int64(v) < lo) || (v > 0 && uint64(v) > hi) {
+			return fmt.Errorf("failed to convert element %d (%v) to %T: value out of range [%d, %d]", i, v, *new(S), lo, hi)
+		}
+	}
+
+	return nil
+}
+
+// validateFloatToInteger checks if v can be converted to an integer type
+// of bit size bits after applying rounding mode
+func validateFloatToInteger(v float64, bits int, signed bool, o *castOptions) error {
+	if math.IsNaN(v) {
+		return fmt.Errorf("NaN has no integer representation")
+	}
+
+	if o.saturate {
+		return nil
+	}
+
+	switch o.rounding {
+	case RoundHalfEven:
+		v = math.RoundToEven(v)
+	case RoundFloor:
+		v = math.Floor(v)
+	case RoundCeil:
+		v = math.Ceil(v)
+	default:
+		v = math.Trunc(v)
+	}
+
+	// upper bound is an exclusive power of two, which is exact in float64
+	lo, hi := 0.0, math.Ldexp(1, bits)
+	if signed {
+		lo, hi = -math.Ldexp(1, bits-1), math.Ldexp(1, bits-1)
+	}
+
+	if v < lo || v >= hi {
+		return fmt.Errorf("value out of range [%v, %v)", lo, hi)
+	}
+
+	return nil
+}
+
+// parseInteger parses s as an integer of data type T. Out of range
+// values are clamped to range of T if saturate is set
+func parseInteger[T PrimitiveTypes](s string, saturate bool) (T, error) {
+	var value T
+	bits, signed, _ := integerType[T]()
+
+	if signed {
+		// out of range values are returned clamped along with an error
+		n, err := strconv.ParseInt(s, 10, bits)
+		if err != nil && !(saturate && errors.Is(err, strconv.ErrRange)) {
+			return value, err
+		}
+		setInteger(&value, n)
+
+		return value, nil
+	}
+
+	n, err := strconv.ParseUint(s, 10, bits)
+	if err != nil && !(saturate && errors.Is(err, strconv.ErrRange)) {
+		// negative values are below range of unsigned types
+		_, magnitudeErr := strconv.ParseUint(strings.TrimPrefix(s, "-"), 10, 64)
+		if strings.HasPrefix(s, "-") && (magnitudeErr == nil || errors.Is(magnitudeErr, strconv.ErrRange)) {
+			if saturate {
+				return value, nil
+			}
+			return value, fmt.Errorf("value out of range [0, %d]", uint64(1)<<bits-1)
+		}
+		return value, err
+	}
+	setInteger(&value, n)
+
+	return value, nil
+}
+
+// checkFormat checks that options formatting values as strings apply to
+// input data type T, since AsString op only accepts precision,
+// scientific and shortest notation for float and complex inputs
+func checkFormat[T PrimitiveTypes](o *castOptions) error {
+	if len(o.fill) > 1 || (len(o.fill) == 1 && !strings.Contains(" +-0#", o.fill)) {
+		return fmt.Errorf("fill needs to be one of ' ', '+', '-', '0' or '#', got %q", o.fill)
+	}
+
+	switch any(*new(T)).(type) {
+	case float32, float64, complex64, complex128, string:
+		return nil
+	}
+
+	if o.precision >= 0 || o.scientific || o.shortest {
+		return fmt.Errorf("precision, scientific and shortest notation are not supported for data type %T", *new(T))
+	}
+
+	return nil
+}
+
+// integerLimits returns minimum and maximum value of an integer type of
+// bit size bits
+func integerLimits(bits int, signed bool) (int64, uint64) {
+	if signed {
+		hi := uint64(math.MaxUint64) >> (65 - bits)
+		return -int64(hi) - 1, hi
+	}
+
+	return 0, uint64(math.MaxUint64) >> (64 - bits)
+}
+
+// integerRange returns the range of an integer type of bit size bits as
+// float values that are exactly representable with a mantissa of given
+// number of bits. Maximum value is rounded down when it is not
+// representable, so that it does not overflow when cast back
+func integerRange(bits int, signed bool, mantissa int) (float64, float64) {
+	lo, k := 0.0, bits
+	if signed {
+		lo, k = -math.Ldexp(1, bits-1), bits-1
+	}
+
+	// 2^k - 1 is exact if k fits mantissa, otherwise the largest
+	// representable value below 2^k is 2^k - 2^(k - mantissa)
+	if k <= mantissa {
+		return lo, math.Ldexp(1, k) - 1
+	}
+
+	return lo, math.Ldexp(1, k) - math.Ldexp(1, k-mantissa)
+}
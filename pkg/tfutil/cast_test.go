@@ -0,0 +1,250 @@
+package tfutil
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCastStringToNumber(t *testing.T) {
+	x, err := NewTensor([]string{"1.5", " -2 ", "3e2", "0"}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Cast[float64](x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{1.5, -2, 300, 0}) || !equal(y.shape, []int{2, 2}) {
+		t.Fatal("output does not match expected value")
+	}
+
+	z, err := NewTensor([]string{"12", "-7", "300"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Cast[int8](z); err == nil || !strings.Contains(err.Error(), "element 2") {
+		t.Fatal("expected cast to fail for out of range element 2, got", err)
+	}
+
+	w, err := Cast[int8](z, CastSaturate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(w.value, []int8{12, -7, 127}) {
+		t.Fatal("saturated output does not match expected value")
+	}
+
+	// values out of int64 range saturate as well
+	big, err := NewTensor([]string{"99999999999999999999", "-99999999999999999999"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Cast[int64](big, CastSaturate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(v.value, []int64{math.MaxInt64, math.MinInt64}) {
+		t.Fatal("saturated output does not match expected value:", v.value)
+	}
+
+	u, err := Cast[uint8](big, CastSaturate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(u.value, []uint8{255, 0}) {
+		t.Fatal("saturated output does not match expected value:", u.value)
+	}
+
+	maxUint64, err := NewTensor([]string{"18446744073709551615"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Cast[uint64](maxUint64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.value[0] != math.MaxUint64 {
+		t.Fatal("output does not match expected value:", m.value)
+	}
+
+	negative, err := NewTensor([]string{"1", "-5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Cast[uint32](negative); err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Fatal("expected cast to fail for negative element 1, got", err)
+	}
+}
+
+func TestCastStringInvalid(t *testing.T) {
+	x, err := NewTensor([]string{"1", "2", "abc", "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Cast[float32](x)
+	if err == nil || !strings.Contains(err.Error(), "element 2") {
+		t.Fatal("expected cast to fail pointing at element 2, got", err)
+	}
+}
+
+func TestCastStringToBool(t *testing.T) {
+	x, err := NewTensor([]string{"true", "False", "1", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Cast[bool](x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []bool{true, false, true, false}) {
+		t.Fatal("output does not match expected value")
+	}
+}
+
+func TestCastNumberToString(t *testing.T) {
+	x, err := NewTensor([]float64{3.14159, 2.71828})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Cast[string](x, CastPrecision(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []string{"3.14", "2.72"}) {
+		t.Fatal("output does not match expected value:", y.value)
+	}
+
+	z, err := NewTensor([]int32{7, 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := Cast[string](z, CastWidth(4), CastFill("0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(w.value, []string{"0007", "0042"}) {
+		t.Fatal("output does not match expected value:", w.value)
+	}
+
+	if _, err := Cast[string](z, CastPrecision(2)); err == nil {
+		t.Fatal("expected cast to fail for precision of integer input")
+	}
+
+	if _, err := Cast[string](z, CastFill("x")); err == nil {
+		t.Fatal("expected cast to fail for unsupported fill")
+	}
+}
+
+func TestCastFloatToIntModes(t *testing.T) {
+	x, err := NewTensor([]float64{1.5, 2.5, -1.5, -0.7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode     RoundingMode
+		expected []int32
+	}{
+		{RoundTruncate, []int32{1, 2, -1, 0}},
+		{RoundHalfEven, []int32{2, 2, -2, -1}},
+		{RoundFloor, []int32{1, 2, -2, -1}},
+		{RoundCeil, []int32{2, 3, -1, 0}},
+	}
+
+	for _, test := range tests {
+		y, err := Cast[int32](x, CastRounding(test.mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !equal(y.value, test.expected) {
+			t.Fatal("output does not match expected value for mode", test.mode, y.value)
+		}
+	}
+}
+
+func TestCastFloatToIntSaturate(t *testing.T) {
+	x, err := NewTensor([]float32{-1000, 100, 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Cast[uint8](x); err == nil || !strings.Contains(err.Error(), "element 0") {
+		t.Fatal("expected cast to fail for out of range element 0, got", err)
+	}
+
+	y, err := Cast[uint8](x, CastSaturate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []uint8{0, 100, 255}) {
+		t.Fatal("output does not match expected value")
+	}
+}
+
+func TestCastIntToIntSaturate(t *testing.T) {
+	x, err := NewTensor([]int64{-1000, 100, 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Cast[int8](x); err == nil || !strings.Contains(err.Error(), "element 0") {
+		t.Fatal("expected cast to fail for out of range element 0, got", err)
+	}
+
+	y, err := Cast[int8](x, CastSaturate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []int8{-128, 100, 127}) {
+		t.Fatal("output does not match expected value:", y.value)
+	}
+
+	u, err := NewTensor([]uint64{1, math.MaxUint64})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Cast[int64](u); err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Fatal("expected cast to fail for out of range element 1, got", err)
+	}
+
+	v, err := Cast[int64](u, CastSaturate(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(v.value, []int64{1, math.MaxInt64}) {
+		t.Fatal("output does not match expected value:", v.value)
+	}
+
+	// widening casts are not affected
+	w, err := Cast[int32](y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(w.value, []int32{-128, 100, 127}) {
+		t.Fatal("output does not match expected value:", w.value)
+	}
+}
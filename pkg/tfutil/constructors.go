@@ -0,0 +1,239 @@
+package tfutil
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// IndexingXY is cartesian indexing for Meshgrid, in which first two
+	// output dimensions are swapped relative to the order of inputs
+	IndexingXY = "xy"
+	// IndexingIJ is matrix indexing for Meshgrid, in which output
+	// dimensions follow the order of inputs
+	IndexingIJ = "ij"
+)
+
+// Zeros creates a new tensor of input shape with all elements
+// set to zero value of the data type
+func Zeros[T PrimitiveTypes](shape ...int) (*Tensor[T], error) {
+	return Full(*new(T), shape...)
+}
+
+// Ones creates a new tensor of input shape with all elements
+// set to one
+func Ones[T NumericTypes](shape ...int) (*Tensor[T], error) {
+	return Full(T(1), shape...)
+}
+
+// Full creates a new tensor of input shape with all elements
+// set to input value
+func Full[T PrimitiveTypes](value T, shape ...int) (*Tensor[T], error) {
+	n, err := numElements(shape)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shape: %w", err)
+	}
+
+	values := make([]T, n)
+	for i := range values {
+		values[i] = value
+	}
+
+	return NewTensor(values, shape...)
+}
+
+// Eye creates an identity matrix of shape rows x cols with ones
+// on the main diagonal. If batchShape is provided, output shape
+// is batchShape followed by rows and cols, with each matrix in
+// the batch being an identity matrix.
+func Eye[T NumericTypes](rows, cols int, batchShape ...int) (*Tensor[T], error) {
+	shape := append(clone(batchShape), rows, cols)
+	output, err := Zeros[T](shape...)
+	if err != nil {
+		return nil, err
+	}
+
+	for b := 0; b < len(output.value); b += rows * cols {
+		for i := 0; i < rows && i < cols; i++ {
+			output.value[b+i*cols+i] = 1
+		}
+	}
+
+	return output, nil
+}
+
+// Arange creates a vector of evenly spaced values within half-open
+// interval [start, stop) such that consecutive values differ by step.
+// step can be negative, but not zero.
+func Arange[T RealTypes](start, stop, step T) (*Tensor[T], error) {
+	if step == 0 {
+		return nil, fmt.Errorf("step can't be zero")
+	}
+
+	n := int(math.Ceil((float64(stop) - float64(start)) / float64(step)))
+	if n <= 0 {
+		return nil, fmt.Errorf("interval [%v, %v) with step %v contains no values", start, stop, step)
+	}
+
+	return NewTensorFromFunc(
+		func(i int) T { return start + T(i)*step },
+		n,
+	)
+}
+
+// Linspace creates a vector of num evenly spaced values over closed
+// interval [start, stop]
+func Linspace[T FloatTypes](start, stop T, num int) (*Tensor[T], error) {
+	if num <= 0 {
+		return nil, fmt.Errorf("num needs to be positive, got %d", num)
+	}
+
+	if num == 1 {
+		return NewTensor([]T{start})
+	}
+
+	step := (stop - start) / T(num-1)
+	return NewTensorFromFunc(
+		func(i int) T {
+			// avoid accumulated rounding at the end of interval
+			if i == num-1 {
+				return stop
+			}
+			return start + T(i)*step
+		},
+		num,
+	)
+}
+
+// Logspace creates a vector of num values spaced evenly on a log scale,
+// i.e., base raised to the power of values from Linspace(start, stop, num)
+func Logspace[T FloatTypes](start, stop T, num int, base T) (*Tensor[T], error) {
+	output, err := Linspace(start, stop, num)
+	if err != nil {
+		return nil, err
+	}
+
+	output.ApplyFunc(
+		func(v T) T { return T(math.Pow(float64(base), float64(v))) },
+	)
+
+	return output, nil
+}
+
+// Diag creates a tensor with input values on the diagonal of its
+// innermost matrices. For an input of shape [..., n] output has
+// shape [..., n, n] with all off-diagonal elements set to zero value
+func Diag[T PrimitiveTypes](input *Tensor[T]) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	n := input.shape[len(input.shape)-1]
+	shape := append(clone(input.shape), n)
+
+	output, err := Zeros[T](shape...)
+	if err != nil {
+		return nil, err
+	}
+
+	for b := 0; b < len(input.value)/n; b++ {
+		for i := 0; i < n; i++ {
+			output.value[b*n*n+i*n+i] = input.value[b*n+i]
+		}
+	}
+
+	return output, nil
+}
+
+// DiagPart extracts main diagonal of innermost matrices of input tensor.
+// For an input of shape [..., M, N] output has shape [..., min(M, N)]
+func DiagPart[T PrimitiveTypes](input *Tensor[T]) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(input.shape) < 2 {
+		return nil, fmt.Errorf("input needs to have rank at least 2, got %d", len(input.shape))
+	}
+
+	rows, cols := input.shape[len(input.shape)-2], input.shape[len(input.shape)-1]
+	k := min(rows, cols)
+	shape := append(clone(input.shape[:len(input.shape)-2]), k)
+
+	values := make([]T, len(input.value)/(rows*cols)*k)
+	for b := 0; b < len(values)/k; b++ {
+		for i := 0; i < k; i++ {
+			values[b*k+i] = input.value[b*rows*cols+i*cols+i]
+		}
+	}
+
+	return NewTensor(values, shape...)
+}
+
+// Meshgrid creates coordinate tensors from input vectors. Each output
+// has rank equal to the number of inputs and k-th output holds values
+// of k-th input broadcast along all other dimensions. indexing is either
+// IndexingXY, in which case first two output dimensions are swapped
+// as in cartesian coordinates, or IndexingIJ for matrix indexing.
+func Meshgrid[T PrimitiveTypes](indexing string, vectors ...*Tensor[T]) ([]*Tensor[T], error) {
+	if indexing != IndexingXY && indexing != IndexingIJ {
+		return nil, fmt.Errorf("invalid indexing %q, expected %q or %q", indexing, IndexingXY, IndexingIJ)
+	}
+
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("at least one input vector is needed")
+	}
+
+	// dims maps k-th input to its dimension in outputs
+	dims := make([]int, len(vectors))
+	shape := make([]int, len(vectors))
+	for k, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("input %d can't be nil", k)
+		}
+
+		if len(vector.shape) != 1 {
+			return nil, fmt.Errorf("input %d needs to be a vector, got shape %v", k, vector.shape)
+		}
+
+		dims[k] = k
+	}
+
+	if indexing == IndexingXY && len(vectors) > 1 {
+		dims[0], dims[1] = 1, 0
+	}
+
+	for k, vector := range vectors {
+		shape[dims[k]] = len(vector.value)
+	}
+
+	n, err := numElements(shape)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shape: %w", err)
+	}
+
+	// strides[i] is the number of elements spanned by a unit
+	// step along dimension i of the output
+	strides := make([]int, len(shape))
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = 1
+		if i < len(shape)-1 {
+			strides[i] = shape[i+1] * strides[i+1]
+		}
+	}
+
+	outputs := make([]*Tensor[T], len(vectors))
+	for k, vector := range vectors {
+		dim := dims[k]
+		values := make([]T, n)
+		for i := range values {
+			values[i] = vector.value[(i/strides[dim])%shape[dim]]
+		}
+
+		if outputs[k], err = NewTensor(values, clone(shape)...); err != nil {
+			return nil, err
+		}
+	}
+
+	return outputs, nil
+}
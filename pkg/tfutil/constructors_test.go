@@ -0,0 +1,190 @@
+package tfutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestZerosOnesFull(t *testing.T) {
+	zeros, err := Zeros[float64](2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(zeros.value, make([]float64, 6)) || !equal(zeros.shape, []int{2, 3}) {
+		t.Fatal("zeros tensor does not match expected value")
+	}
+
+	ones, err := Ones[complex64](3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(ones.value, []complex64{1, 1, 1}) {
+		t.Fatal("ones tensor does not match expected value")
+	}
+
+	full, err := Full("x", 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(full.value, []string{"x", "x", "x", "x"}) {
+		t.Fatal("full tensor does not match expected value")
+	}
+
+	if _, err := Zeros[int32](2, 0); err == nil {
+		t.Fatal("expected zeros to fail for invalid shape")
+	}
+}
+
+func TestEye(t *testing.T) {
+	eye, err := Eye[int32](2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(eye.value, []int32{1, 0, 0, 0, 1, 0}) || !equal(eye.shape, []int{2, 3}) {
+		t.Fatal("eye tensor does not match expected value")
+	}
+
+	eye, err = Eye[int32](2, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(eye.value, []int32{1, 0, 0, 1, 1, 0, 0, 1}) || !equal(eye.shape, []int{2, 2, 2}) {
+		t.Fatal("batched eye tensor does not match expected value")
+	}
+}
+
+func TestArange(t *testing.T) {
+	x, err := Arange[int64](1, 10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(x.value, []int64{1, 4, 7}) {
+		t.Fatal("arange output does not match expected value")
+	}
+
+	y, err := Arange(1.0, 0.0, -0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{1, 0.75, 0.5, 0.25}) {
+		t.Fatal("arange output does not match expected value")
+	}
+
+	if _, err := Arange[int32](0, 5, 0); err == nil {
+		t.Fatal("expected arange to fail for zero step")
+	}
+
+	if _, err := Arange[int32](5, 0, 1); err == nil {
+		t.Fatal("expected arange to fail for empty interval")
+	}
+}
+
+func TestLinspaceLogspace(t *testing.T) {
+	x, err := Linspace(0.0, 1.0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(x.value, []float64{0, 0.25, 0.5, 0.75, 1}) {
+		t.Fatal("linspace output does not match expected value")
+	}
+
+	y, err := Logspace(0.0, 3.0, 4, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range []float64{1, 10, 100, 1000} {
+		if math.Abs(y.value[i]-v) > 1e-9 {
+			t.Fatal("logspace output does not match expected value")
+		}
+	}
+}
+
+func TestDiagDiagPart(t *testing.T) {
+	x, err := NewTensor([]int32{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Diag(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(d.value, []int32{1, 0, 0, 2, 3, 0, 0, 4}) || !equal(d.shape, []int{2, 2, 2}) {
+		t.Fatal("diag output does not match expected value")
+	}
+
+	p, err := DiagPart(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(p.value, x.value) || !equal(p.shape, x.shape) {
+		t.Fatal("diag part output does not match expected value")
+	}
+
+	y, err := NewTensorFromFunc(func(i int) int32 { return int32(i) }, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = DiagPart(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(p.value, []int32{0, 4}) || !equal(p.shape, []int{2}) {
+		t.Fatal("diag part output does not match expected value")
+	}
+}
+
+func TestMeshgrid(t *testing.T) {
+	x, err := NewTensor([]int32{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]int32{4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xy, err := Meshgrid(IndexingXY, x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(xy[0].value, []int32{1, 2, 3, 1, 2, 3}) || !equal(xy[0].shape, []int{2, 3}) {
+		t.Fatal("meshgrid xy output does not match expected value")
+	}
+
+	if !equal(xy[1].value, []int32{4, 4, 4, 5, 5, 5}) || !equal(xy[1].shape, []int{2, 3}) {
+		t.Fatal("meshgrid xy output does not match expected value")
+	}
+
+	ij, err := Meshgrid(IndexingIJ, x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(ij[0].value, []int32{1, 1, 2, 2, 3, 3}) || !equal(ij[0].shape, []int{3, 2}) {
+		t.Fatal("meshgrid ij output does not match expected value")
+	}
+
+	if !equal(ij[1].value, []int32{4, 5, 4, 5, 4, 5}) || !equal(ij[1].shape, []int{3, 2}) {
+		t.Fatal("meshgrid ij output does not match expected value")
+	}
+
+	if _, err := Meshgrid("xyz", x, y); err == nil {
+		t.Fatal("expected meshgrid to fail for invalid indexing")
+	}
+}
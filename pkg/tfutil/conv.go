@@ -0,0 +1,631 @@
+package tfutil
+
+import (
+	"fmt"
+	"math"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+const (
+	// PaddingSame pads input such that output spatial dimensions are
+	// input dimensions divided by strides, rounded up
+	PaddingSame = "SAME"
+	// PaddingValid does not pad input, so windows only cover
+	// positions fully within input
+	PaddingValid = "VALID"
+	// PaddingExplicit pads input by amounts set via ConvExplicitPadding
+	PaddingExplicit = "EXPLICIT"
+)
+
+const (
+	// DataFormatNHWC is channels last data format, i.e., shape
+	// [batch, height, width, channels], or [batch, width, channels]
+	// for one dimensional inputs
+	DataFormatNHWC = "NHWC"
+	// DataFormatNCHW is channels first data format, i.e., shape
+	// [batch, channels, height, width], or [batch, channels, width]
+	// for one dimensional inputs
+	DataFormatNCHW = "NCHW"
+)
+
+// ConvOption configures convolution and pooling
+type ConvOption func(*convOptions)
+
+type convOptions struct {
+	strides    []int
+	dilations  []int
+	padding    string
+	paddings   [][2]int
+	dataFormat string
+}
+
+// ConvStrides sets strides along spatial dimensions. A single value
+// applies to all spatial dimensions. Default is 1 for convolution
+// and window size for pooling
+func ConvStrides(strides ...int) ConvOption {
+	return func(o *convOptions) {
+		o.strides = strides
+	}
+}
+
+// ConvDilations sets dilation rates along spatial dimensions, i.e.,
+// spacing between filter elements. A single value applies to all
+// spatial dimensions. Default is 1
+func ConvDilations(dilations ...int) ConvOption {
+	return func(o *convOptions) {
+		o.dilations = dilations
+	}
+}
+
+// ConvPadding sets padding to either PaddingSame or PaddingValid.
+// Default is PaddingValid
+func ConvPadding(padding string) ConvOption {
+	return func(o *convOptions) {
+		o.padding = padding
+	}
+}
+
+// ConvExplicitPadding sets padding to PaddingExplicit with amounts to
+// pad before and after each spatial dimension
+func ConvExplicitPadding(paddings ...[2]int) ConvOption {
+	return func(o *convOptions) {
+		o.padding = PaddingExplicit
+		o.paddings = paddings
+	}
+}
+
+// ConvDataFormat sets data format to either DataFormatNHWC or
+// DataFormatNCHW. Default is DataFormatNHWC
+func ConvDataFormat(format string) ConvOption {
+	return func(o *convOptions) {
+		o.dataFormat = format
+	}
+}
+
+func newConvOptions(options []ConvOption) *convOptions {
+	o := &convOptions{
+		padding:    PaddingValid,
+		dataFormat: DataFormatNHWC,
+	}
+
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// checkDataFormat checks that data format is either NHWC or NCHW
+func (o *convOptions) checkDataFormat() error {
+	if o.dataFormat != DataFormatNHWC && o.dataFormat != DataFormatNCHW {
+		return fmt.Errorf("invalid data format %q, expected %q or %q", o.dataFormat, DataFormatNHWC, DataFormatNCHW)
+	}
+
+	return nil
+}
+
+// window holds validated window parameters for spatial dimensions
+type window struct {
+	strides   []int
+	dilations []int
+	paddings  [][2]int
+}
+
+// window validates options for given number of spatial dimensions.
+// defaultStrides are used when strides are not set
+func (o *convOptions) window(spatial int, defaultStrides []int) (*window, error) {
+	if err := o.checkDataFormat(); err != nil {
+		return nil, err
+	}
+
+	expand := func(name string, values, defaults []int) ([]int, error) {
+		switch len(values) {
+		case 0:
+			values = defaults
+		case 1:
+			v := values[0]
+			values = make([]int, spatial)
+			for i := range values {
+				values[i] = v
+			}
+		case spatial:
+		default:
+			return nil, fmt.Errorf("%s need 1 or %d values, got %v", name, spatial, values)
+		}
+
+		for _, v := range values {
+			if v <= 0 {
+				return nil, fmt.Errorf("%s need to be positive, got %v", name, values)
+			}
+		}
+
+		return clone(values), nil
+	}
+
+	ones := make([]int, spatial)
+	for i := range ones {
+		ones[i] = 1
+	}
+
+	w := &window{}
+	var err error
+	if w.strides, err = expand("strides", o.strides, defaultStrides); err != nil {
+		return nil, err
+	}
+
+	if w.dilations, err = expand("dilations", o.dilations, ones); err != nil {
+		return nil, err
+	}
+
+	switch o.padding {
+	case PaddingSame, PaddingValid:
+	case PaddingExplicit:
+		if len(o.paddings) != spatial {
+			return nil, fmt.Errorf("explicit padding needs %d values, got %v", spatial, o.paddings)
+		}
+
+		for _, p := range o.paddings {
+			if p[0] < 0 || p[1] < 0 {
+				return nil, fmt.Errorf("explicit padding can't be negative, got %v", o.paddings)
+			}
+		}
+
+		w.paddings = o.paddings
+	default:
+		return nil, fmt.Errorf("invalid padding %q, expected %q, %q or %q", o.padding, PaddingSame, PaddingValid, PaddingExplicit)
+	}
+
+	return w, nil
+}
+
+// outputSize computes output length of a spatial dimension of length n
+// for window of size k along i-th spatial dimension
+func (w *window) outputSize(i, n, k int, padding string) (int, error) {
+	// effective window size accounts for dilation
+	k = (k-1)*w.dilations[i] + 1
+	s := w.strides[i]
+
+	var size int
+	switch padding {
+	case PaddingSame:
+		size = (n + s - 1) / s
+	case PaddingValid:
+		size = (n - k + s) / s
+		if n < k {
+			size = 0
+		}
+	default:
+		n += w.paddings[i][0] + w.paddings[i][1]
+		size = (n - k + s) / s
+		if n < k {
+			size = 0
+		}
+	}
+
+	if size <= 0 {
+		return 0, fmt.Errorf(
+			"spatial dimension %d of length %d is smaller than effective window size %d",
+			i, n, k,
+		)
+	}
+
+	return size, nil
+}
+
+// explicitPaddings returns paddings in the layout of tensorflow
+// explicit_paddings attribute for NHWC inputs
+func (w *window) explicitPaddings() []int64 {
+	if w.paddings == nil {
+		return nil
+	}
+
+	paddings := []int64{0, 0}
+	for _, p := range w.paddings {
+		paddings = append(paddings, int64(p[0]), int64(p[1]))
+	}
+
+	return append(paddings, 0, 0)
+}
+
+// toNHWC returns shape of input in NHWC layout along with permutations
+// to convert input to NHWC and back, which are nil for NHWC inputs
+func toNHWC(shape []int, dataFormat string) ([]int, []int64, []int64) {
+	if dataFormat == DataFormatNHWC {
+		return clone(shape), nil, nil
+	}
+
+	rank := len(shape)
+	perm := []int64{0}
+	inverse := []int64{0, int64(rank - 1)}
+	for i := 2; i < rank; i++ {
+		perm = append(perm, int64(i))
+		inverse = append(inverse, int64(i-1))
+	}
+	perm = append(perm, 1)
+
+	nhwc := make([]int, rank)
+	for i, p := range perm {
+		nhwc[i] = shape[p]
+	}
+
+	return nhwc, perm, inverse
+}
+
+// Conv1D computes one dimensional convolution of input of shape
+// [batch, width, inChannels] with filter of shape [width, inChannels,
+// outChannels]. Output has shape [batch, outWidth, outChannels].
+// Channels come before width for DataFormatNCHW
+func Conv1D[T FloatTypes](input, filter *Tensor[T], options ...ConvOption) (*Tensor[T], error) {
+	if input == nil || filter == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(input.shape) != 3 || len(filter.shape) != 3 {
+		return nil, fmt.Errorf("input and filter need to have rank 3, got shapes %v and %v", input.shape, filter.shape)
+	}
+
+	o := newConvOptions(options)
+	w, err := o.window(1, []int{1})
+	if err != nil {
+		return nil, err
+	}
+
+	// one dimensional convolution is computed as two dimensional
+	// convolution over inputs with unit height
+	o2 := &convOptions{
+		strides:    []int{1, w.strides[0]},
+		dilations:  []int{1, w.dilations[0]},
+		padding:    o.padding,
+		dataFormat: o.dataFormat,
+	}
+
+	if w.paddings != nil {
+		o2.paddings = [][2]int{{0, 0}, w.paddings[0]}
+	}
+
+	heightAxis := 1
+	if o.dataFormat == DataFormatNCHW {
+		heightAxis = 2
+	}
+
+	x := &Tensor[T]{value: input.value, shape: insertAxis(input.shape, heightAxis)}
+	f := &Tensor[T]{value: filter.value, shape: insertAxis(filter.shape, 0)}
+
+	output, err := conv2D(x, f, o2, false)
+	if err != nil {
+		return nil, err
+	}
+
+	output.shape = append(output.shape[:heightAxis], output.shape[heightAxis+1:]...)
+	return output, nil
+}
+
+// Conv2D computes two dimensional convolution of input of shape
+// [batch, height, width, inChannels] with filter of shape
+// [filterHeight, filterWidth, inChannels, outChannels]. Output has
+// shape [batch, outHeight, outWidth, outChannels]. Channels come
+// before spatial dimensions for DataFormatNCHW
+func Conv2D[T FloatTypes](input, filter *Tensor[T], options ...ConvOption) (*Tensor[T], error) {
+	return conv2D(input, filter, newConvOptions(options), false)
+}
+
+// DepthwiseConv2D convolves each input channel separately with filter of
+// shape [filterHeight, filterWidth, inChannels, multiplier], resulting in
+// output of shape [batch, outHeight, outWidth, inChannels * multiplier]
+func DepthwiseConv2D[T FloatTypes](input, filter *Tensor[T], options ...ConvOption) (*Tensor[T], error) {
+	return conv2D(input, filter, newConvOptions(options), true)
+}
+
+// conv2D computes regular or depthwise two dimensional convolution
+func conv2D[T FloatTypes](input, filter *Tensor[T], o *convOptions, depthwise bool) (*Tensor[T], error) {
+	if input == nil || filter == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(input.shape) != 4 || len(filter.shape) != 4 {
+		return nil, fmt.Errorf("input and filter need to have rank 4, got shapes %v and %v", input.shape, filter.shape)
+	}
+
+	w, err := o.window(2, []int{1, 1})
+	if err != nil {
+		return nil, err
+	}
+
+	shape, perm, inverse := toNHWC(input.shape, o.dataFormat)
+	if shape[3] != filter.shape[2] {
+		return nil, fmt.Errorf(
+			"input channels %d do not match filter input channels %d of filter of shape %v",
+			shape[3], filter.shape[2], filter.shape,
+		)
+	}
+
+	outShape := []int{shape[0], 0, 0, filter.shape[3]}
+	if depthwise {
+		outShape[3] = shape[3] * filter.shape[3]
+	}
+
+	for i := 0; i < 2; i++ {
+		if outShape[i+1], err = w.outputSize(i, shape[i+1], filter.shape[i], o.padding); err != nil {
+			return nil, err
+		}
+	}
+
+	strides := []int64{1, int64(w.strides[0]), int64(w.strides[1]), 1}
+	dilations := []int64{1, int64(w.dilations[0]), int64(w.dilations[1]), 1}
+
+	return windowOutput(input, filter, perm, inverse, outShape, func(scope *op.Scope, x, f tf.Output) tf.Output {
+		if depthwise {
+			return op.DepthwiseConv2dNative(
+				scope, x, f, strides, o.padding,
+				op.DepthwiseConv2dNativeDilations(dilations),
+				op.DepthwiseConv2dNativeExplicitPaddings(w.explicitPaddings()),
+			)
+		}
+
+		return op.Conv2D(
+			scope, x, f, strides, o.padding,
+			op.Conv2DDilations(dilations),
+			op.Conv2DExplicitPaddings(w.explicitPaddings()),
+		)
+	})
+}
+
+// Conv2DTranspose computes transpose of two dimensional convolution, i.e.,
+// gradient of Conv2D with respect to its input, for input of shape
+// [batch, height, width, inChannels] and filter of shape [filterHeight,
+// filterWidth, outChannels, inChannels]. Output spatial dimensions are
+// input spatial dimensions times strides for PaddingSame, as in keras,
+// which is the largest size for which Conv2D with same options results in
+// input spatial dimensions. For other paddings they are the smallest such
+// size
+func Conv2DTranspose[T FloatTypes](input, filter *Tensor[T], options ...ConvOption) (*Tensor[T], error) {
+	if input == nil || filter == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(input.shape) != 4 || len(filter.shape) != 4 {
+		return nil, fmt.Errorf("input and filter need to have rank 4, got shapes %v and %v", input.shape, filter.shape)
+	}
+
+	o := newConvOptions(options)
+	w, err := o.window(2, []int{1, 1})
+	if err != nil {
+		return nil, err
+	}
+
+	shape, perm, inverse := toNHWC(input.shape, o.dataFormat)
+	if shape[3] != filter.shape[3] {
+		return nil, fmt.Errorf(
+			"input channels %d do not match filter input channels %d of filter of shape %v",
+			shape[3], filter.shape[3], filter.shape,
+		)
+	}
+
+	outShape := []int{shape[0], 0, 0, filter.shape[2]}
+	for i := 0; i < 2; i++ {
+		n, k, s := shape[i+1], (filter.shape[i]-1)*w.dilations[i]+1, w.strides[i]
+		switch o.padding {
+		case PaddingSame:
+			outShape[i+1] = n * s
+		case PaddingValid:
+			outShape[i+1] = (n-1)*s + k
+		default:
+			outShape[i+1] = (n-1)*s + k - w.paddings[i][0] - w.paddings[i][1]
+		}
+
+		if outShape[i+1] <= 0 {
+			return nil, fmt.Errorf("explicit padding %v leaves no output along spatial dimension %d", w.paddings[i], i)
+		}
+	}
+
+	strides := []int64{1, int64(w.strides[0]), int64(w.strides[1]), 1}
+	dilations := []int64{1, int64(w.dilations[0]), int64(w.dilations[1]), 1}
+
+	return windowOutput(input, filter, perm, inverse, outShape, func(scope *op.Scope, x, f tf.Output) tf.Output {
+		return op.Conv2DBackpropInput(
+			scope,
+			op.Const(scope.SubScope("inputSizes"), castToInt32(outShape)),
+			f, x, strides, o.padding,
+			op.Conv2DBackpropInputDilations(dilations),
+			op.Conv2DBackpropInputExplicitPaddings(w.explicitPaddings()),
+		)
+	})
+}
+
+// MaxPool computes maximum over windows of size poolHeight x poolWidth
+// for input of shape [batch, height, width, channels]. Explicit padding
+// pads with negative infinity
+func MaxPool[T FloatTypes](input *Tensor[T], poolHeight, poolWidth int, options ...ConvOption) (*Tensor[T], error) {
+	return pool(input, poolHeight, poolWidth, newConvOptions(options), true)
+}
+
+// AvgPool computes average over windows of size poolHeight x poolWidth
+// for input of shape [batch, height, width, channels]. Padded positions
+// are excluded from averages for PaddingSame and included as zeros for
+// explicit padding
+func AvgPool[T FloatTypes](input *Tensor[T], poolHeight, poolWidth int, options ...ConvOption) (*Tensor[T], error) {
+	return pool(input, poolHeight, poolWidth, newConvOptions(options), false)
+}
+
+// pool computes max or average pooling
+func pool[T FloatTypes](input *Tensor[T], poolHeight, poolWidth int, o *convOptions, isMax bool) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(input.shape) != 4 {
+		return nil, fmt.Errorf("input needs to have rank 4, got shape %v", input.shape)
+	}
+
+	if poolHeight <= 0 || poolWidth <= 0 {
+		return nil, fmt.Errorf("pool size needs to be positive, got %d x %d", poolHeight, poolWidth)
+	}
+
+	w, err := o.window(2, []int{poolHeight, poolWidth})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range w.dilations {
+		if d != 1 {
+			return nil, fmt.Errorf("pooling does not support dilations, got %v", w.dilations)
+		}
+	}
+
+	shape, perm, inverse := toNHWC(input.shape, o.dataFormat)
+	outShape := []int{shape[0], 0, 0, shape[3]}
+	for i, k := range []int{poolHeight, poolWidth} {
+		if outShape[i+1], err = w.outputSize(i, shape[i+1], k, o.padding); err != nil {
+			return nil, err
+		}
+	}
+
+	ksize := []int64{1, int64(poolHeight), int64(poolWidth), 1}
+	strides := []int64{1, int64(w.strides[0]), int64(w.strides[1]), 1}
+
+	return windowOutput(input, nil, perm, inverse, outShape, func(scope *op.Scope, x, _ tf.Output) tf.Output {
+		padding := o.padding
+		if padding == PaddingExplicit {
+			paddings := op.Const(scope.SubScope("paddings"), [][]int64{
+				{0, 0},
+				{int64(w.paddings[0][0]), int64(w.paddings[0][1])},
+				{int64(w.paddings[1][0]), int64(w.paddings[1][1])},
+				{0, 0},
+			})
+
+			value := 0.0
+			if isMax {
+				value = math.Inf(-1)
+			}
+
+			x = op.PadV2(
+				scope, x, paddings,
+				op.Cast(scope.SubScope("value"), op.Const(scope.SubScope("value"), value), x.DataType()),
+			)
+			padding = PaddingValid
+		}
+
+		if isMax {
+			return op.MaxPool(scope, x, ksize, strides, padding)
+		}
+
+		return op.AvgPool(scope, x, ksize, strides, padding)
+	})
+}
+
+// GlobalMaxPool computes maximum over all spatial dimensions of input of
+// shape [batch, spatial..., channels], resulting in shape [batch, channels]
+func GlobalMaxPool[T FloatTypes](input *Tensor[T], options ...ConvOption) (*Tensor[T], error) {
+	return globalPool(input, newConvOptions(options), true)
+}
+
+// GlobalAvgPool computes average over all spatial dimensions of input of
+// shape [batch, spatial..., channels], resulting in shape [batch, channels]
+func GlobalAvgPool[T FloatTypes](input *Tensor[T], options ...ConvOption) (*Tensor[T], error) {
+	return globalPool(input, newConvOptions(options), false)
+}
+
+// globalPool reduces spatial dimensions via maximum or average
+func globalPool[T FloatTypes](input *Tensor[T], o *convOptions, isMax bool) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(input.shape) < 3 {
+		return nil, fmt.Errorf("input needs to have rank at least 3, got shape %v", input.shape)
+	}
+
+	if err := o.checkDataFormat(); err != nil {
+		return nil, err
+	}
+
+	// spatial axes follow batch axis for NHWC and channel axis for NCHW
+	var axes []int64
+	for i := 1; i < len(input.shape)-1; i++ {
+		axes = append(axes, int64(i))
+		if o.dataFormat == DataFormatNCHW {
+			axes[len(axes)-1]++
+		}
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			axes := op.Const(scope.SubScope("axes"), axes)
+			if isMax {
+				return op.Max(scope, outputs[0], axes), nil
+			}
+
+			return op.Mean(scope, outputs[0], axes), nil
+		},
+		input,
+	)
+}
+
+// windowOutput runs a windowed operation f over input, and optionally
+// filter, in NHWC layout, transposing input and output via perm and
+// inverse if they are not nil. Output is checked against expected NHWC
+// shape computed in Go
+func windowOutput[T FloatTypes](
+	input, filter *Tensor[T],
+	perm, inverse []int64,
+	shape []int,
+	f func(scope *op.Scope, x, filter tf.Output) tf.Output,
+) (*Tensor[T], error) {
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	feeds := map[tf.Output]*tf.Tensor{X: x}
+
+	var F tf.Output
+	if filter != nil {
+		y, err := filter.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+		}
+
+		F = placeholder(root, "F", y)
+		feeds[F] = y
+	}
+
+	if perm != nil {
+		X = op.Transpose(root.SubScope("toNHWC"), X, op.Const(root.SubScope("perm"), perm))
+	}
+
+	Output := f(root, X, F)
+
+	if inverse != nil {
+		Output = op.Transpose(root.SubScope("fromNHWC"), Output, op.Const(root.SubScope("inverse"), inverse))
+	}
+
+	output, err := runOutput[T](root, feeds, Output)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := clone(shape)
+	if inverse != nil {
+		for i, p := range inverse {
+			expected[i] = shape[p]
+		}
+	}
+
+	if !equal(output.shape, expected) {
+		return nil, fmt.Errorf("expected output of shape %v, got %v", expected, output.shape)
+	}
+
+	return output, nil
+}
+
+// insertAxis returns shape with a unit dimension inserted at axis
+func insertAxis(shape []int, axis int) []int {
+	output := make([]int, 0, len(shape)+1)
+	output = append(output, shape[:axis]...)
+	output = append(output, 1)
+	return append(output, shape[axis:]...)
+}
@@ -0,0 +1,228 @@
+package tfutil
+
+import (
+	"testing"
+)
+
+func TestConv1D(t *testing.T) {
+	x, err := NewTensor([]float32{1, 2, 3, 4}, 1, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewTensor([]float32{1, 1}, 2, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Conv1D(x, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float32{3, 5, 7}) || !equal(y.shape, []int{1, 3, 1}) {
+		t.Fatal("conv1d output does not match expected value")
+	}
+
+	y, err = Conv1D(x, f, ConvPadding(PaddingSame), ConvStrides(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.shape, []int{1, 2, 1}) {
+		t.Fatal("conv1d output shape does not match expected value")
+	}
+}
+
+func TestConv2D(t *testing.T) {
+	x, err := NewTensorFromFunc(func(i int) float64 { return float64(i + 1) }, 1, 3, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Ones[float64](2, 2, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Conv2D(x, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{12, 16, 24, 28}) || !equal(y.shape, []int{1, 2, 2, 1}) {
+		t.Fatal("conv2d output does not match expected value")
+	}
+
+	y, err = Conv2D(x, f, ConvPadding(PaddingSame))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.shape, []int{1, 3, 3, 1}) {
+		t.Fatal("conv2d output shape does not match expected value")
+	}
+
+	y, err = Conv2D(x, f, ConvDilations(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{20}) || !equal(y.shape, []int{1, 1, 1, 1}) {
+		t.Fatal("dilated conv2d output does not match expected value")
+	}
+
+	y, err = Conv2D(x, f, ConvExplicitPadding([2]int{1, 0}, [2]int{0, 0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{3, 5, 12, 16, 24, 28}) || !equal(y.shape, []int{1, 3, 2, 1}) {
+		t.Fatal("explicitly padded conv2d output does not match expected value")
+	}
+
+	// same values in channels first layout
+	nchw := &Tensor[float64]{value: x.value, shape: []int{1, 1, 3, 3}}
+	y, err = Conv2D(nchw, f, ConvDataFormat(DataFormatNCHW))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{12, 16, 24, 28}) || !equal(y.shape, []int{1, 1, 2, 2}) {
+		t.Fatal("nchw conv2d output does not match expected value")
+	}
+
+	g, err := Ones[float64](2, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Conv2D(x, g); err == nil {
+		t.Fatal("expected conv2d to fail for mismatched channels")
+	}
+
+	h, err := Ones[float64](4, 4, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Conv2D(x, h); err == nil {
+		t.Fatal("expected conv2d to fail for filter larger than input")
+	}
+}
+
+func TestDepthwiseConv2D(t *testing.T) {
+	x, err := NewTensor([]float32{1, 10, 2, 20, 3, 30, 4, 40}, 1, 2, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewTensor([]float32{1, 2, 3, 4}, 1, 1, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := DepthwiseConv2D(x, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.shape, []int{1, 2, 2, 4}) || !equal(y.value[:4], []float32{1, 2, 30, 40}) {
+		t.Fatal("depthwise conv2d output does not match expected value")
+	}
+}
+
+func TestConv2DTranspose(t *testing.T) {
+	x, err := Ones[float64](1, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Ones[float64](2, 2, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Conv2DTranspose(x, f, ConvStrides(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := Ones[float64](1, 4, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, expected.value) || !equal(y.shape, expected.shape) {
+		t.Fatal("conv2d transpose output does not match expected value")
+	}
+}
+
+func TestPool(t *testing.T) {
+	x, err := NewTensorFromFunc(func(i int) float64 { return float64(i) }, 1, 4, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := MaxPool(x, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{5, 7, 13, 15}) || !equal(y.shape, []int{1, 2, 2, 1}) {
+		t.Fatal("max pool output does not match expected value")
+	}
+
+	y, err = AvgPool(x, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{2.5, 4.5, 10.5, 12.5}) {
+		t.Fatal("avg pool output does not match expected value")
+	}
+
+	// explicit padding for max pool should not introduce zeros
+	z, err := NewTensor([]float64{-1, -2, -3, -4}, 1, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err = MaxPool(z, 2, 2, ConvExplicitPadding([2]int{1, 1}, [2]int{1, 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{-1, -2, -3, -4}) {
+		t.Fatal("explicitly padded max pool output does not match expected value")
+	}
+
+	if _, err := MaxPool(x, 2, 2, ConvDilations(2)); err == nil {
+		t.Fatal("expected max pool to fail for dilations")
+	}
+}
+
+func TestGlobalPool(t *testing.T) {
+	x, err := NewTensor([]float32{1, 10, 2, 20, 3, 30, 4, 40}, 1, 2, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := GlobalAvgPool(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float32{2.5, 25}) || !equal(y.shape, []int{1, 2}) {
+		t.Fatal("global avg pool output does not match expected value")
+	}
+
+	y, err = GlobalMaxPool(x, ConvDataFormat(DataFormatNCHW))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float32{20, 40}) || !equal(y.shape, []int{1, 2}) {
+		t.Fatal("global max pool output does not match expected value")
+	}
+}
@@ -0,0 +1,491 @@
+package tfutil
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// MissingPolicy defines how missing values in CSV input are handled
+type MissingPolicy int
+
+const (
+	// MissingError fails reading on a missing value
+	MissingError MissingPolicy = iota
+	// MissingDefault replaces missing values with default value set via
+	// CSVDefault
+	MissingDefault
+	// MissingNaN replaces missing values with NaN and is only valid for
+	// float and complex data types
+	MissingNaN
+)
+
+// CSVOption configures reading and writing of CSV data
+type CSVOption func(*csvOptions)
+
+type csvOptions struct {
+	delimiter     rune
+	header        bool
+	columnNames   []string
+	columns       []int
+	skipRows      int
+	maxRows       int
+	missing       MissingPolicy
+	defaultValue  string
+	missingTokens []string
+	precision     int
+}
+
+// CSVDelimiter sets field delimiter, such as '\t' for TSV data.
+// Default is ','
+func CSVDelimiter(value rune) CSVOption {
+	return func(o *csvOptions) {
+		o.delimiter = value
+	}
+}
+
+// CSVHeader sets whether first record of input is a header holding
+// column names, which is then not parsed as values. Default is false
+func CSVHeader(value bool) CSVOption {
+	return func(o *csvOptions) {
+		o.header = value
+	}
+}
+
+// CSVColumnNames selects columns to read by their names in the header,
+// in the given order, and requires CSVHeader. When writing, names are
+// written as a header and need to match number of columns
+func CSVColumnNames(names ...string) CSVOption {
+	return func(o *csvOptions) {
+		o.columnNames = names
+	}
+}
+
+// CSVColumns selects columns to read by their zero based indices, in the
+// given order. Default is all columns
+func CSVColumns(indices ...int) CSVOption {
+	return func(o *csvOptions) {
+		o.columns = indices
+	}
+}
+
+// CSVSkipRows skips given number of records following the header
+func CSVSkipRows(value int) CSVOption {
+	return func(o *csvOptions) {
+		o.skipRows = value
+	}
+}
+
+// CSVMaxRows limits number of records read after skipped ones. Default
+// is 0, which reads all records
+func CSVMaxRows(value int) CSVOption {
+	return func(o *csvOptions) {
+		o.maxRows = value
+	}
+}
+
+// CSVMissing sets policy for missing values. Default is MissingError
+func CSVMissing(policy MissingPolicy) CSVOption {
+	return func(o *csvOptions) {
+		o.missing = policy
+	}
+}
+
+// CSVDefault sets value replacing missing values under MissingDefault,
+// which is parsed like any other field. Default is empty, which stands
+// for zero value of data type
+func CSVDefault(value string) CSVOption {
+	return func(o *csvOptions) {
+		o.defaultValue = value
+	}
+}
+
+// CSVMissingTokens sets fields that denote a missing value, such as "NA",
+// compared after trimming surrounding whitespace. Default is an empty
+// field
+func CSVMissingTokens(tokens ...string) CSVOption {
+	return func(o *csvOptions) {
+		o.missingTokens = tokens
+	}
+}
+
+// CSVPrecision sets number of digits after decimal point when writing
+// float and complex values. Default is -1, which writes the shortest
+// representation that reads back to the same value
+func CSVPrecision(value int) CSVOption {
+	return func(o *csvOptions) {
+		o.precision = value
+	}
+}
+
+func newCSVOptions(options []CSVOption) *csvOptions {
+	o := &csvOptions{
+		delimiter:     ',',
+		missing:       MissingError,
+		missingTokens: []string{""},
+		precision:     -1,
+	}
+
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// ReadCSV reads CSV data from r into a tensor of shape [rows, columns]
+// holding selected records and columns. Fields are parsed via strconv
+// after trimming surrounding whitespace, except for strings which are
+// kept as is. Parse errors report line, field index and byte offset
+// within the line of the offending field
+func ReadCSV[T PrimitiveTypes](r io.Reader, options ...CSVOption) (*Tensor[T], error) {
+	values, rows, columns, _, err := readCSV[T](r, newCSVOptions(options))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTensor(values, rows, columns)
+}
+
+// ReadCSVColumns reads CSV data from r as one tensor of shape [rows] per
+// selected column along with names of selected columns, which are nil
+// without CSVHeader. See ReadCSV for details
+func ReadCSVColumns[T PrimitiveTypes](r io.Reader, options ...CSVOption) (columns []*Tensor[T], names []string, err error) {
+	values, rows, numColumns, names, err := readCSV[T](r, newCSVOptions(options))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns = make([]*Tensor[T], numColumns)
+	for j := range columns {
+		column := make([]T, rows)
+		for i := range column {
+			column[i] = values[i*numColumns+j]
+		}
+
+		if columns[j], err = NewTensor(column, rows); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return columns, names, nil
+}
+
+// WriteCSV writes tensor of rank 1 as a single column or of rank 2 as
+// rows to w. Float and complex values are formatted as per CSVPrecision
+func WriteCSV[T PrimitiveTypes](w io.Writer, tensor *Tensor[T], options ...CSVOption) error {
+	if tensor == nil {
+		return fmt.Errorf("tensor can't be nil")
+	}
+
+	o := newCSVOptions(options)
+
+	var rows, columns int
+	switch len(tensor.shape) {
+	case 1:
+		rows, columns = tensor.shape[0], 1
+	case 2:
+		rows, columns = tensor.shape[0], tensor.shape[1]
+	default:
+		return fmt.Errorf("tensor needs rank 1 or 2, got shape %v", tensor.shape)
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = o.delimiter
+
+	if len(o.columnNames) > 0 {
+		if len(o.columnNames) != columns {
+			return fmt.Errorf("expected %d column names, got %d", columns, len(o.columnNames))
+		}
+
+		if err := writer.Write(o.columnNames); err != nil {
+			return fmt.Errorf("failed to write csv header: %w", err)
+		}
+	}
+
+	record := make([]string, columns)
+	for i := 0; i < rows; i++ {
+		for j := range record {
+			record[j] = formatCSVValue(tensor.value[i*columns+j], o.precision)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv record %d: %w", i, err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	return nil
+}
+
+// readCSV reads selected records and columns of CSV data into values in
+// row major order
+func readCSV[T PrimitiveTypes](r io.Reader, o *csvOptions) (values []T, rows, columns int, names []string, err error) {
+	if err := checkCSVOptions[T](o); err != nil {
+		return nil, 0, 0, nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = o.delimiter
+
+	var header []string
+	if o.header {
+		if header, err = reader.Read(); err != nil {
+			if err == io.EOF {
+				return nil, 0, 0, nil, fmt.Errorf("csv input has no header")
+			}
+			return nil, 0, 0, nil, fmt.Errorf("failed to read csv header: %w", err)
+		}
+	}
+
+	var defaultValue T
+	if o.missing == MissingDefault && o.defaultValue != "" {
+		if defaultValue, err = parseCSVValue[T](o.defaultValue); err != nil {
+			return nil, 0, 0, nil, fmt.Errorf("failed to parse default value %q as %T: %w", o.defaultValue, defaultValue, err)
+		}
+	}
+
+	var selected []int
+	for index := 0; o.maxRows <= 0 || rows < o.maxRows; index++ {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, 0, 0, nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		if index < o.skipRows {
+			continue
+		}
+
+		// columns are resolved against first record, since all
+		// records need to have equal number of fields
+		if selected == nil {
+			if selected, err = selectCSVColumns(header, len(record), o); err != nil {
+				return nil, 0, 0, nil, err
+			}
+		}
+
+		for _, j := range selected {
+			field := record[j]
+			if _, isString := any(*new(T)).(string); !isString {
+				field = strings.TrimSpace(field)
+			}
+
+			value, err := parseCSVField[T](field, defaultValue, o)
+			if err != nil {
+				// field position is reported as 1-based field index
+				// along with 1-based byte offset within the line
+				line, column := reader.FieldPos(j)
+				return nil, 0, 0, nil, fmt.Errorf("line %d, field %d (byte %d): %w", line, j+1, column, err)
+			}
+
+			values = append(values, value)
+		}
+
+		rows++
+	}
+
+	if rows == 0 {
+		return nil, 0, 0, nil, fmt.Errorf("csv input has no records to read")
+	}
+
+	if header != nil {
+		names = make([]string, len(selected))
+		for i, j := range selected {
+			names[i] = header[j]
+		}
+	}
+
+	return values, rows, len(selected), names, nil
+}
+
+// parseCSVField parses a single field handling missing values as per
+// policy
+func parseCSVField[T PrimitiveTypes](field string, defaultValue T, o *csvOptions) (T, error) {
+	for _, token := range o.missingTokens {
+		if strings.TrimSpace(field) != token {
+			continue
+		}
+
+		var value T
+		switch o.missing {
+		case MissingDefault:
+			return defaultValue, nil
+		case MissingNaN:
+			switch p := any(&value).(type) {
+			case *float32:
+				*p = float32(math.NaN())
+			case *float64:
+				*p = math.NaN()
+			case *complex64:
+				*p = complex64(cmplx.NaN())
+			case *complex128:
+				*p = cmplx.NaN()
+			}
+			return value, nil
+		}
+
+		return value, fmt.Errorf("missing value %q", field)
+	}
+
+	value, err := parseCSVValue[T](field)
+	if err != nil {
+		return value, fmt.Errorf("failed to parse %q as %T: %w", field, value, err)
+	}
+
+	return value, nil
+}
+
+// parseCSVValue parses s as a value of data type T via strconv
+func parseCSVValue[T PrimitiveTypes](s string) (T, error) {
+	var value T
+	var err error
+
+	switch p := any(&value).(type) {
+	case *string:
+		*p = s
+	case *bool:
+		*p, err = strconv.ParseBool(s)
+	case *float32:
+		var v float64
+		v, err = strconv.ParseFloat(s, 32)
+		*p = float32(v)
+	case *float64:
+		*p, err = strconv.ParseFloat(s, 64)
+	case *complex64:
+		var v complex128
+		v, err = strconv.ParseComplex(s, 64)
+		*p = complex64(v)
+	case *complex128:
+		*p, err = strconv.ParseComplex(s, 128)
+	default:
+		bits, signed, _ := integerType[T]()
+		if signed {
+			var v int64
+			v, err = strconv.ParseInt(s, 10, bits)
+			setInteger(&value, v)
+		} else {
+			var v uint64
+			v, err = strconv.ParseUint(s, 10, bits)
+			setInteger(&value, v)
+		}
+	}
+
+	// strconv errors already quote input
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	}
+
+	return value, err
+}
+
+// formatCSVValue formats v with given number of digits after decimal
+// point for float and complex values
+func formatCSVValue[T PrimitiveTypes](v T, precision int) string {
+	format := byte('f')
+	if precision < 0 {
+		format = 'g'
+	}
+
+	switch v := any(v).(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), format, precision, 32)
+	case float64:
+		return strconv.FormatFloat(v, format, precision, 64)
+	case complex64:
+		return strconv.FormatComplex(complex128(v), format, precision, 64)
+	case complex128:
+		return strconv.FormatComplex(v, format, precision, 128)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// selectCSVColumns resolves indices of selected columns for records of
+// given number of fields
+func selectCSVColumns(header []string, numFields int, o *csvOptions) ([]int, error) {
+	if header != nil && len(header) != numFields {
+		return nil, fmt.Errorf("csv header has %d fields, records have %d", len(header), numFields)
+	}
+
+	if len(o.columnNames) > 0 {
+		selected := make([]int, len(o.columnNames))
+		for i, name := range o.columnNames {
+			selected[i] = -1
+			for j, h := range header {
+				if h == name {
+					selected[i] = j
+					break
+				}
+			}
+
+			if selected[i] < 0 {
+				return nil, fmt.Errorf("column %q not found in csv header", name)
+			}
+		}
+
+		return selected, nil
+	}
+
+	if len(o.columns) > 0 {
+		for _, j := range o.columns {
+			if j < 0 || j >= numFields {
+				return nil, fmt.Errorf("column index %d out of range [0, %d)", j, numFields)
+			}
+		}
+
+		return clone(o.columns), nil
+	}
+
+	selected := make([]int, numFields)
+	for j := range selected {
+		selected[j] = j
+	}
+
+	return selected, nil
+}
+
+// checkCSVOptions validates options for reading values of data type T
+func checkCSVOptions[T PrimitiveTypes](o *csvOptions) error {
+	if len(o.columnNames) > 0 && len(o.columns) > 0 {
+		return fmt.Errorf("columns can't be selected by both names and indices")
+	}
+
+	if len(o.columnNames) > 0 && !o.header {
+		return fmt.Errorf("selecting columns by names needs a header")
+	}
+
+	if o.skipRows < 0 || o.maxRows < 0 {
+		return fmt.Errorf("rows to skip and max rows can't be negative, got %d and %d", o.skipRows, o.maxRows)
+	}
+
+	switch o.missing {
+	case MissingError, MissingDefault:
+	case MissingNaN:
+		switch any(*new(T)).(type) {
+		case float32, float64, complex64, complex128:
+		default:
+			return fmt.Errorf("missing values can't be NaN for data type %T", *new(T))
+		}
+	default:
+		return fmt.Errorf("invalid missing value policy %d", o.missing)
+	}
+
+	return nil
+}
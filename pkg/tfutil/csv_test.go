@@ -0,0 +1,111 @@
+package tfutil
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := "id\tx\ty\n" +
+		"0\t1.5\t2\n" +
+		"1\tNA\t4\n" +
+		"2\t 5.5 \t6\n" +
+		"3\t7.5\t8\n"
+
+	output, err := ReadCSV[float64](
+		strings.NewReader(input),
+		CSVDelimiter('\t'),
+		CSVHeader(true),
+		CSVColumnNames("y", "x"),
+		CSVSkipRows(1),
+		CSVMaxRows(2),
+		CSVMissing(MissingNaN),
+		CSVMissingTokens("NA"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(output.shape, []int{2, 2}) {
+		t.Fatalf("expected shape [2 2], got %v", output.shape)
+	}
+
+	if output.value[0] != 4 || !math.IsNaN(output.value[1]) || output.value[2] != 6 || output.value[3] != 5.5 {
+		t.Fatalf("unexpected values %v", output.value)
+	}
+
+	columns, names, err := ReadCSVColumns[int32](
+		strings.NewReader("a,b\n1,\n3,4\n"),
+		CSVHeader(true),
+		CSVMissing(MissingDefault),
+		CSVDefault("-1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(names, []string{"a", "b"}) || len(columns) != 2 {
+		t.Fatalf("unexpected columns %v", names)
+	}
+
+	if !equal(columns[0].value, []int32{1, 3}) || !equal(columns[1].value, []int32{-1, 4}) {
+		t.Fatalf("unexpected column values %v and %v", columns[0].value, columns[1].value)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	_, err := ReadCSV[int8](strings.NewReader("1,2\n3,300\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2, field 2 (byte 3)") {
+		t.Fatalf("expected error at line 2, field 2, got %v", err)
+	}
+
+	_, err = ReadCSV[float32](strings.NewReader("1,2\n,4\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2, field 1 (byte 1)") {
+		t.Fatalf("expected missing value error at line 2, field 1, got %v", err)
+	}
+
+	if _, err := ReadCSV[int64](strings.NewReader("1\n"), CSVMissing(MissingNaN)); err == nil {
+		t.Fatal("expected error for NaN policy on integers")
+	}
+
+	if _, err := ReadCSV[int64](strings.NewReader("1\n"), CSVColumns(1)); err == nil {
+		t.Fatal("expected error for column out of range")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	input, err := NewTensor([]float64{1, 2.25, 3.125, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteCSV(buf, input, CSVColumnNames("a", "b"), CSVPrecision(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "a,b\n1.00,2.25\n3.12,4.00\n"; buf.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, buf.String())
+	}
+
+	strs, err := NewTensor([]string{"x", "y,z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := WriteCSV(buf, strs); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := ReadCSV[string](buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(output.shape, []int{2, 1}) || !equal(output.value, strs.value) {
+		t.Fatalf("csv round trip of strings does not match input, got %q", output.value)
+	}
+}
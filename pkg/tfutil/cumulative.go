@@ -0,0 +1,218 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// CumulativeOption configures cumulative operators such as Cumsum.
+// These options mirror exclusive and reverse attributes of the
+// corresponding tensorflow ops
+type CumulativeOption func(*cumulativeOptions)
+
+type cumulativeOptions struct {
+	exclusive bool
+	reverse   bool
+}
+
+// CumulativeExclusive sets exclusive mode, in which an element of the
+// output does not include the corresponding element of the input. For
+// instance, exclusive cumulative sum of [a, b, c] is [0, a, a + b]
+func CumulativeExclusive(value bool) CumulativeOption {
+	return func(o *cumulativeOptions) {
+		o.exclusive = value
+	}
+}
+
+// CumulativeReverse sets reverse mode, in which accumulation runs from
+// the end of the axis. For instance, reverse cumulative sum of [a, b, c]
+// is [a + b + c, b + c, c]
+func CumulativeReverse(value bool) CumulativeOption {
+	return func(o *cumulativeOptions) {
+		o.reverse = value
+	}
+}
+
+func newCumulativeOptions(options []CumulativeOption) *cumulativeOptions {
+	o := &cumulativeOptions{}
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// CumsumOp returns an operator that computes cumulative sum along axis.
+// Negative axis counts from the last dimension
+func CumsumOp(axis int, options ...CumulativeOption) Operator {
+	return func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+		if len(outputs) != 1 {
+			return tf.Output{}, fmt.Errorf("operator Cumsum needs len outputs = 1, got %d", len(outputs))
+		}
+
+		if err := checkAxis(outputs[0], axis); err != nil {
+			return tf.Output{}, fmt.Errorf("invalid axis for operator Cumsum: %w", err)
+		}
+
+		o := newCumulativeOptions(options)
+		return op.Cumsum(
+			scope,
+			outputs[0],
+			op.Const(scope.SubScope("axis"), int64(axis)),
+			op.CumsumExclusive(o.exclusive),
+			op.CumsumReverse(o.reverse),
+		), nil
+	}
+}
+
+// CumprodOp returns an operator that computes cumulative product along axis.
+// Negative axis counts from the last dimension
+func CumprodOp(axis int, options ...CumulativeOption) Operator {
+	return func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+		if len(outputs) != 1 {
+			return tf.Output{}, fmt.Errorf("operator Cumprod needs len outputs = 1, got %d", len(outputs))
+		}
+
+		if err := checkAxis(outputs[0], axis); err != nil {
+			return tf.Output{}, fmt.Errorf("invalid axis for operator Cumprod: %w", err)
+		}
+
+		o := newCumulativeOptions(options)
+		return op.Cumprod(
+			scope,
+			outputs[0],
+			op.Const(scope.SubScope("axis"), int64(axis)),
+			op.CumprodExclusive(o.exclusive),
+			op.CumprodReverse(o.reverse),
+		), nil
+	}
+}
+
+// CumulativeLogsumexpOp returns an operator that computes cumulative
+// log-sum-exp along axis, i.e., log of cumulative sum of exponentials
+// without an intermediate overflow. It is only defined for float types.
+// Negative axis counts from the last dimension
+func CumulativeLogsumexpOp(axis int, options ...CumulativeOption) Operator {
+	return func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+		if len(outputs) != 1 {
+			return tf.Output{}, fmt.Errorf("operator CumulativeLogsumexp needs len outputs = 1, got %d", len(outputs))
+		}
+
+		if err := checkAxis(outputs[0], axis); err != nil {
+			return tf.Output{}, fmt.Errorf("invalid axis for operator CumulativeLogsumexp: %w", err)
+		}
+
+		o := newCumulativeOptions(options)
+		return op.CumulativeLogsumexp(
+			scope,
+			outputs[0],
+			op.Const(scope.SubScope("axis"), int64(axis)),
+			op.CumulativeLogsumexpExclusive(o.exclusive),
+			op.CumulativeLogsumexpReverse(o.reverse),
+		), nil
+	}
+}
+
+// DiffOp returns an operator that computes n-th discrete difference
+// along axis, i.e., out[i] = in[i+1] - in[i] applied n times. The
+// size of the axis shrinks by n and must remain positive.
+// Negative axis counts from the last dimension
+func DiffOp(n, axis int) Operator {
+	return func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+		if len(outputs) != 1 {
+			return tf.Output{}, fmt.Errorf("operator Diff needs len outputs = 1, got %d", len(outputs))
+		}
+
+		// slicing below needs static shape of the input
+		shape, err := outputs[0].Shape().ToSlice()
+		if err != nil {
+			return tf.Output{}, fmt.Errorf("operator Diff needs known input shape: %w", err)
+		}
+
+		axis, err := normalizeAxis(axis, len(shape))
+		if err != nil {
+			return tf.Output{}, fmt.Errorf("invalid axis for operator Diff: %w", err)
+		}
+
+		if n < 0 {
+			return tf.Output{}, fmt.Errorf("operator Diff needs non-negative n, got %d", n)
+		}
+
+		if shape[axis] < 0 {
+			return tf.Output{}, fmt.Errorf("operator Diff needs known size of axis %d", axis)
+		}
+
+		if int64(n) >= shape[axis] {
+			return tf.Output{}, fmt.Errorf(
+				"operator Diff needs n less than size %d of axis %d, got %d", shape[axis], axis, n,
+			)
+		}
+
+		x := outputs[0]
+		if n == 0 {
+			return op.Identity(scope, x), nil
+		}
+
+		for i := 0; i < n; i++ {
+			// upper starts at index 1 along axis and lower at index 0,
+			// both spanning all but one element along that axis
+			size := make([]int64, len(shape))
+			for j := range size {
+				size[j] = -1
+			}
+			size[axis] = shape[axis] - int64(i) - 1
+
+			begin := make([]int64, len(shape))
+			lower := op.Slice(
+				scope.SubScope("lower"),
+				x,
+				op.Const(scope.SubScope("begin"), begin),
+				op.Const(scope.SubScope("size"), size),
+			)
+
+			begin = make([]int64, len(shape))
+			begin[axis] = 1
+			upper := op.Slice(
+				scope.SubScope("upper"),
+				x,
+				op.Const(scope.SubScope("begin"), begin),
+				op.Const(scope.SubScope("size"), size),
+			)
+
+			x = op.Sub(scope.SubScope("diff"), upper, lower)
+		}
+
+		return x, nil
+	}
+}
+
+// Cumsum computes cumulative sum of input tensor along axis
+func Cumsum[T PrimitiveTypes](input *Tensor[T], axis int, options ...CumulativeOption) (*Tensor[T], error) {
+	return Apply(
+		CumsumOp(axis, options...), input,
+	)
+}
+
+// Cumprod computes cumulative product of input tensor along axis
+func Cumprod[T PrimitiveTypes](input *Tensor[T], axis int, options ...CumulativeOption) (*Tensor[T], error) {
+	return Apply(
+		CumprodOp(axis, options...), input,
+	)
+}
+
+// CumulativeLogsumexp computes cumulative log-sum-exp of input tensor
+// along axis. Input is expected to be of float data type
+func CumulativeLogsumexp[T PrimitiveTypes](input *Tensor[T], axis int, options ...CumulativeOption) (*Tensor[T], error) {
+	return Apply(
+		CumulativeLogsumexpOp(axis, options...), input,
+	)
+}
+
+// Diff computes n-th discrete difference of input tensor along axis
+func Diff[T PrimitiveTypes](input *Tensor[T], n, axis int) (*Tensor[T], error) {
+	return Apply(
+		DiffOp(n, axis), input,
+	)
+}
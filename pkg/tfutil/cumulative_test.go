@@ -0,0 +1,124 @@
+package tfutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCumsum(t *testing.T) {
+	x, err := NewTensor([]int32{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Cumsum(x, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []int32{1, 3, 6, 4, 9, 15}) {
+		t.Fatal("output values do not match expected values")
+	}
+
+	if !equal(y.shape, []int{2, 3}) {
+		t.Fatal("output shape does not match expected shape")
+	}
+}
+
+func TestCumsumExclusiveReverse(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Cumsum(x, 0, CumulativeExclusive(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{0, 1, 3, 6}) {
+		t.Fatal("exclusive output values do not match expected values")
+	}
+
+	y, err = Cumsum(x, -1, CumulativeReverse(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []float64{10, 9, 7, 4}) {
+		t.Fatal("reverse output values do not match expected values")
+	}
+}
+
+func TestCumsumInvalidAxis(t *testing.T) {
+	x, err := NewTensor([]int64{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Cumsum(x, 2); err == nil {
+		t.Fatal("expected cumsum to fail for out of range axis")
+	}
+}
+
+func TestCumprod(t *testing.T) {
+	x, err := NewTensor([]int64{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := x.Apply(CumprodOp(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(x.value, []int64{1, 2, 3, 4, 10, 18}) {
+		t.Fatal("output values do not match expected values")
+	}
+}
+
+func TestCumulativeLogsumexp(t *testing.T) {
+	x, err := NewTensor([]float64{0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := CumulativeLogsumexp(x, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range y.value {
+		if math.Abs(v-math.Log(float64(i+1))) > 1e-9 {
+			t.Fatal("output values do not match expected values")
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	x, err := NewTensor([]int32{1, 2, 4, 7, 0, 1, 1, 3}, 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Diff(x, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []int32{1, 2, 3, 1, 0, 2}) || !equal(y.shape, []int{2, 3}) {
+		t.Fatal("first difference does not match expected values")
+	}
+
+	y, err = Diff(x, 2, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []int32{1, 1, -1, 2}) || !equal(y.shape, []int{2, 2}) {
+		t.Fatal("second difference does not match expected values")
+	}
+
+	if _, err := Diff(x, 4, 1); err == nil {
+		t.Fatal("expected diff to fail when n is not less than axis size")
+	}
+}
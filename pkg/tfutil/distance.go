@@ -0,0 +1,334 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// Metric defines distance between rows of tensors
+type Metric int
+
+const (
+	// MetricEuclidean is euclidean distance
+	MetricEuclidean Metric = iota
+	// MetricSquaredEuclidean is squared euclidean distance
+	MetricSquaredEuclidean
+	// MetricManhattan is sum of absolute differences
+	MetricManhattan
+	// MetricCosine is one minus cosine similarity
+	MetricCosine
+)
+
+// KNearestOption configures nearest neighbour search by KNearest
+type KNearestOption func(*kNearestOptions)
+
+type kNearestOptions struct {
+	metric    Metric
+	chunkSize int
+}
+
+// KNearestMetric sets metric used to compare queries and points.
+// Default is MetricEuclidean
+func KNearestMetric(metric Metric) KNearestOption {
+	return func(o *kNearestOptions) {
+		o.metric = metric
+	}
+}
+
+// KNearestChunkSize sets number of points compared against all queries
+// at a time, which bounds memory used by intermediate distances to
+// number of queries times chunk size, and additionally times number of
+// features for MetricManhattan, which compares all features of each pair
+// at once. Default is 1024
+func KNearestChunkSize(value int) KNearestOption {
+	return func(o *kNearestOptions) {
+		o.chunkSize = value
+	}
+}
+
+func newKNearestOptions(options []KNearestOption) *kNearestOptions {
+	o := &kNearestOptions{
+		metric:    MetricEuclidean,
+		chunkSize: 1024,
+	}
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// PairwiseDistance computes distance between each row of x of shape
+// [N, D] and each row of y of shape [M, D] as per metric. Output has
+// shape [N, M]
+func PairwiseDistance[T FloatTypes](x, y *Tensor[T], metric Metric) (*Tensor[T], error) {
+	if err := checkRows(x, y); err != nil {
+		return nil, err
+	}
+
+	if err := checkMetric(metric); err != nil {
+		return nil, err
+	}
+
+	a, err := x.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	b, err := y.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", a)
+	Y := placeholder(root, "Y", b)
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: a, Y: b}, pairwiseDistance(root, X, Y, metric))
+}
+
+// CosineSimilarity computes cosine similarity between each row of x of
+// shape [N, D] and each row of y of shape [M, D]. Output has shape [N, M].
+// Similarity involving rows of zeros is zero
+func CosineSimilarity[T FloatTypes](x, y *Tensor[T]) (*Tensor[T], error) {
+	if err := checkRows(x, y); err != nil {
+		return nil, err
+	}
+
+	a, err := x.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	b, err := y.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", a)
+	Y := placeholder(root, "Y", b)
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: a, Y: b}, cosineSimilarity(root, X, Y))
+}
+
+// KNearest finds k nearest rows of points of shape [M, D] for each row of
+// queries of shape [N, D]. Output indices and distances have shape [N, k]
+// and are ordered by increasing distance, with ties broken by lower
+// index. Points are processed in chunks, see KNearestChunkSize
+func KNearest[T FloatTypes](queries, points *Tensor[T], k int, options ...KNearestOption) (indices *Tensor[int64], distances *Tensor[T], err error) {
+	if err := checkRows(queries, points); err != nil {
+		return nil, nil, err
+	}
+
+	o := newKNearestOptions(options)
+	if err := checkMetric(o.metric); err != nil {
+		return nil, nil, err
+	}
+
+	if o.chunkSize <= 0 {
+		return nil, nil, fmt.Errorf("chunk size needs to be positive, got %d", o.chunkSize)
+	}
+
+	n, m, d := queries.shape[0], points.shape[0], points.shape[1]
+	if k <= 0 || k > m {
+		return nil, nil, fmt.Errorf("k needs to be in [1, %d], got %d", m, k)
+	}
+
+	q, err := queries.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	session, err := NewSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func(session *Session) {
+		err := session.Close()
+		if err != nil {
+			panic(err)
+		}
+	}(session)
+
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// graph is built once with a chunk of unknown length and k for
+	// a chunk is fed, since last chunk can be shorter than k
+	var Q, P, K, values, positions tf.Output
+	if err := session.extend("kNearest", func(scope *op.Scope) {
+		Q = placeholder(scope, "queries", q)
+		P = op.Placeholder(scope.SubScope("points"), dataType, op.PlaceholderShape(tf.MakeShape(-1, int64(d))))
+		K = op.Placeholder(scope.SubScope("k"), tf.Int32, op.PlaceholderShape(tf.ScalarShape()))
+		values, positions = op.TopKV2(scope, op.Neg(scope, pairwiseDistance(scope, Q, P, o.metric)), K)
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	// best holds up to k nearest candidates per query sorted by
+	// increasing distance, with earlier chunks first on ties
+	best := make([][]neighbour[T], n)
+	for start := 0; start < m; start += o.chunkSize {
+		end := min(start+o.chunkSize, m)
+
+		chunk, err := NewTensor(points.value[start*d:end*d], end-start, d)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		p, err := chunk.Marshal()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+		}
+
+		kChunk, err := tf.NewTensor(int32(min(k, end-start)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+		}
+
+		out, err := session.run(map[tf.Output]*tf.Tensor{Q: q, P: p, K: kChunk}, []tf.Output{values, positions})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		chunkValues, ok := out[0].Value().([][]T)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected distances of data type %T, got %T", *new(T), out[0].Value())
+		}
+
+		chunkPositions, ok := out[1].Value().([][]int32)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected indices of data type int32, got %T", out[1].Value())
+		}
+
+		for i := range best {
+			candidates := make([]neighbour[T], len(chunkValues[i]))
+			for j := range candidates {
+				candidates[j] = neighbour[T]{
+					index:    int64(start) + int64(chunkPositions[i][j]),
+					distance: -chunkValues[i][j],
+				}
+			}
+
+			best[i] = mergeNeighbours(best[i], candidates, k)
+		}
+	}
+
+	indexValues := make([]int64, 0, n*k)
+	distanceValues := make([]T, 0, n*k)
+	for i := range best {
+		for _, c := range best[i] {
+			indexValues = append(indexValues, c.index)
+			distanceValues = append(distanceValues, c.distance)
+		}
+	}
+
+	if indices, err = NewTensor(indexValues, n, k); err != nil {
+		return nil, nil, err
+	}
+
+	if distances, err = NewTensor(distanceValues, n, k); err != nil {
+		return nil, nil, err
+	}
+
+	return indices, distances, nil
+}
+
+// neighbour is a candidate point for a query
+type neighbour[T FloatTypes] struct {
+	index    int64
+	distance T
+}
+
+// mergeNeighbours merges two lists sorted by increasing distance keeping
+// up to k nearest. Elements of x come first on ties
+func mergeNeighbours[T FloatTypes](x, y []neighbour[T], k int) []neighbour[T] {
+	merged := make([]neighbour[T], 0, min(k, len(x)+len(y)))
+	i, j := 0, 0
+	for len(merged) < cap(merged) {
+		if j == len(y) || (i < len(x) && x[i].distance <= y[j].distance) {
+			merged = append(merged, x[i])
+			i++
+		} else {
+			merged = append(merged, y[j])
+			j++
+		}
+	}
+
+	return merged
+}
+
+// pairwiseDistance computes distance between rows of x and rows of y
+func pairwiseDistance(scope *op.Scope, x, y tf.Output, metric Metric) tf.Output {
+	switch metric {
+	case MetricManhattan:
+		// rows are compared via broadcasting to shape [N, M, D]
+		diff := op.Sub(
+			scope,
+			op.ExpandDims(scope, x, op.Const(scope.SubScope("xAxis"), int32(1))),
+			op.ExpandDims(scope, y, op.Const(scope.SubScope("yAxis"), int32(0))),
+		)
+		return op.Sum(scope, op.Abs(scope, diff), op.Const(scope.SubScope("axes"), []int32{2}))
+	case MetricCosine:
+		similarity := cosineSimilarity(scope, x, y)
+		return op.Sub(scope, op.OnesLike(scope, similarity), similarity)
+	}
+
+	// squared distance is expanded as |x|^2 - 2 x.y + |y|^2, which can
+	// turn slightly negative due to rounding and is hence clamped at zero
+	xx := op.Sum(scope, op.Square(scope, x), op.Const(scope.SubScope("xAxes"), []int32{1}), op.SumKeepDims(true))
+	yy := op.Sum(scope, op.Square(scope, y), op.Const(scope.SubScope("yAxes"), []int32{1}), op.SumKeepDims(true))
+	xy := op.MatMul(scope, x, y, op.MatMulTransposeB(true))
+	two := op.Cast(scope.SubScope("two"), op.Const(scope.SubScope("two"), 2.0), x.DataType())
+	squared := op.Add(
+		scope,
+		op.Sub(scope, xx, op.Mul(scope, two, xy)),
+		op.Transpose(scope, yy, op.Const(scope.SubScope("perm"), []int32{1, 0})),
+	)
+	squared = op.Maximum(scope, squared, op.ZerosLike(scope, squared))
+
+	if metric == MetricSquaredEuclidean {
+		return squared
+	}
+
+	return op.Sqrt(scope, squared)
+}
+
+// cosineSimilarity computes cosine similarity between rows of x and rows
+// of y, with rows normalized by their euclidean norm clamped at 1e-12
+func cosineSimilarity(scope *op.Scope, x, y tf.Output) tf.Output {
+	epsilon := op.Cast(scope.SubScope("epsilon"), op.Const(scope.SubScope("epsilon"), 1e-12), x.DataType())
+	x = op.Div(scope, x, op.Maximum(scope, l2Norm(scope.SubScope("xNorm"), x, []int{1}), epsilon))
+	y = op.Div(scope, y, op.Maximum(scope, l2Norm(scope.SubScope("yNorm"), y, []int{1}), epsilon))
+
+	return op.MatMul(scope, x, y, op.MatMulTransposeB(true))
+}
+
+// checkRows checks that x and y are matrices with equal number of columns
+func checkRows[T PrimitiveTypes](x, y *Tensor[T]) error {
+	if x == nil || y == nil {
+		return fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(x.shape) != 2 || len(y.shape) != 2 || x.shape[1] != y.shape[1] {
+		return fmt.Errorf("inputs need to be matrices with equal number of columns, got shapes %v and %v", x.shape, y.shape)
+	}
+
+	return nil
+}
+
+// checkMetric checks that metric is one of the defined metrics
+func checkMetric(metric Metric) error {
+	switch metric {
+	case MetricEuclidean, MetricSquaredEuclidean, MetricManhattan, MetricCosine:
+		return nil
+	}
+
+	return fmt.Errorf("invalid metric %d", metric)
+}
@@ -0,0 +1,106 @@
+package tfutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPairwiseDistance(t *testing.T) {
+	x, err := NewTensor([]float64{0, 0, 1, 1}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float64{1, 0, 0, 1, 3, 4}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		metric   Metric
+		expected []float64
+	}{
+		{MetricEuclidean, []float64{1, 1, 5, 1, 1, math.Sqrt(13)}},
+		{MetricSquaredEuclidean, []float64{1, 1, 25, 1, 1, 13}},
+		{MetricManhattan, []float64{1, 1, 7, 1, 1, 5}},
+		{MetricCosine, []float64{1, 1, 1, 1 - 1/math.Sqrt2, 1 - 1/math.Sqrt2, 1 - 7/(5*math.Sqrt2)}},
+	} {
+		distance, err := PairwiseDistance(x, y, test.metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !allClose(distance.value, test.expected, 1e-9) || !equal(distance.shape, []int{2, 3}) {
+			t.Fatalf("distance for metric %d expected %v, got %v", test.metric, test.expected, distance.value)
+		}
+	}
+
+	similarity, err := CosineSimilarity(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(similarity.value, []float64{0, 0, 0, 1 / math.Sqrt2, 1 / math.Sqrt2, 7 / (5 * math.Sqrt2)}, 1e-9) {
+		t.Fatal("cosine similarity does not match expected value")
+	}
+
+	z, err := NewTensor([]float64{1, 2, 3}, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PairwiseDistance(x, z, MetricEuclidean); err == nil {
+		t.Fatal("expected pairwise distance to fail for mismatched number of columns")
+	}
+}
+
+func TestKNearest(t *testing.T) {
+	// points on a line at x = 0, 1, ..., 9
+	points, err := NewTensorFromFunc(func(i int) float64 {
+		if i%2 == 0 {
+			return float64(i / 2)
+		}
+		return 0
+	}, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries, err := NewTensor([]float64{2.2, 0, 7.9, 0}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// last chunk holds fewer points than k
+	indices, distances, err := KNearest(queries, points, 3, KNearestChunkSize(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(indices.value, []int64{2, 3, 1, 8, 7, 9}) || !equal(indices.shape, []int{2, 3}) {
+		t.Fatalf("expected indices [2 3 1 8 7 9], got %v", indices.value)
+	}
+
+	if !allClose(distances.value, []float64{0.2, 0.8, 1.2, 0.1, 0.9, 1.1}, 1e-6) {
+		t.Fatalf("distances do not match expected value, got %v", distances.value)
+	}
+
+	// ties across chunks are broken by lower index
+	query, err := NewTensor([]float64{0.5, 0}, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indices, _, err = KNearest(query, points, 2, KNearestChunkSize(1), KNearestMetric(MetricManhattan))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(indices.value, []int64{0, 1}) {
+		t.Fatalf("expected indices [0 1], got %v", indices.value)
+	}
+
+	if _, _, err := KNearest(queries, points, 11); err == nil {
+		t.Fatal("expected k nearest to fail for k larger than number of points")
+	}
+}
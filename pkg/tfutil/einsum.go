@@ -0,0 +1,386 @@
+package tfutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+const einsumEllipsis = "..."
+
+// einsumTerm holds subscripts of a single operand or output of an
+// einsum equation
+type einsumTerm struct {
+	// labels are subscript letters excluding ellipsis
+	labels []byte
+	// offsets are positions of labels in the equation
+	offsets []int
+	// ellipsis is the index in labels at which ellipsis occurs
+	// or -1 if the term has no ellipsis
+	ellipsis int
+	// ellipsisOffset is the position of ellipsis in the equation
+	ellipsisOffset int
+	// offset is the position of the term in the equation
+	offset int
+}
+
+// String formats term as subscripts of an einsum equation
+func (term *einsumTerm) String() string {
+	if term.ellipsis < 0 {
+		return string(term.labels)
+	}
+
+	return string(term.labels[:term.ellipsis]) + einsumEllipsis + string(term.labels[term.ellipsis:])
+}
+
+// einsumEquation is a parsed einsum equation in explicit form
+type einsumEquation struct {
+	equation string
+	inputs   []*einsumTerm
+	output   *einsumTerm
+}
+
+// String formats equation in explicit form, i.e., with output subscripts
+func (eq *einsumEquation) String() string {
+	inputs := make([]string, len(eq.inputs))
+	for i, term := range eq.inputs {
+		inputs[i] = term.String()
+	}
+
+	return strings.Join(inputs, ",") + "->" + eq.output.String()
+}
+
+// EinsumOp returns an operator that performs tensor contraction over
+// subscripts defined by an einsum equation such as "ij,jk->ik".
+// Equation is parsed and validated against static shapes of inputs before
+// graph construction. Ellipsis broadcasts over dimensions not covered by
+// subscripts and output subscripts can be omitted, in which case output
+// consists of ellipsis followed by subscripts that appear exactly once
+// in sorted order. Equations with more than two inputs are contracted
+// pairwise from left to right
+func EinsumOp(equation string) Operator {
+	return func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+		if len(outputs) == 0 {
+			return tf.Output{}, fmt.Errorf("operator Einsum needs at least one input")
+		}
+
+		eq, err := parseEinsum(equation, len(outputs))
+		if err != nil {
+			return tf.Output{}, err
+		}
+
+		shapes := make([][]int64, len(outputs))
+		for i, output := range outputs {
+			if shapes[i], err = output.Shape().ToSlice(); err != nil {
+				return tf.Output{}, fmt.Errorf("operator Einsum needs known rank of input %d: %w", i, err)
+			}
+		}
+
+		if _, err := eq.shape(shapes); err != nil {
+			return tf.Output{}, err
+		}
+
+		if len(outputs) <= 2 {
+			return op.Einsum(scope, outputs, eq.String()), nil
+		}
+
+		// tensorflow einsum accepts at most two inputs
+		x, left := outputs[0], eq.inputs[0]
+		for i := 1; i < len(outputs); i++ {
+			next := eq.output
+			if i < len(outputs)-1 {
+				next = eq.intermediate(left, i)
+			}
+
+			x = op.Einsum(
+				scope.SubScope(fmt.Sprintf("einsum%d", i)),
+				[]tf.Output{x, outputs[i]},
+				left.String()+","+eq.inputs[i].String()+"->"+next.String(),
+			)
+			left = next
+		}
+
+		return x, nil
+	}
+}
+
+// Einsum performs tensor contraction over subscripts defined by an
+// einsum equation. For instance, "ij,jk->ik" is matrix multiplication,
+// "ii->i" extracts diagonal and "...ij->...ji" transposes innermost
+// matrices. See EinsumOp for details
+func Einsum[T NumericTypes](equation string, inputs ...*Tensor[T]) (*Tensor[T], error) {
+	root := op.NewScope()
+	feeds := make(map[tf.Output]*tf.Tensor, len(inputs))
+	outputs := make([]tf.Output, len(inputs))
+	for i, input := range inputs {
+		if input == nil {
+			return nil, fmt.Errorf("input %d can't be nil", i)
+		}
+
+		x, err := input.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+		}
+
+		outputs[i] = placeholder(root, fmt.Sprintf("X%d", i), x)
+		feeds[outputs[i]] = x
+	}
+
+	output, err := EinsumOp(equation)(root, outputs...)
+	if err != nil {
+		return nil, err
+	}
+
+	// output is built via runOutput rather than Apply, since scalar
+	// outputs such as a trace are returned as a vector of length 1
+	return runOutput[T](root, feeds, output)
+}
+
+// parseEinsum parses an einsum equation for given number of inputs.
+// Output subscripts are inferred if equation is in implicit form
+func parseEinsum(equation string, numInputs int) (*einsumEquation, error) {
+	eq := &einsumEquation{equation: equation}
+
+	lhs, rhs, explicit := strings.Cut(equation, "->")
+	if explicit && strings.Contains(rhs, "->") {
+		return nil, fmt.Errorf(
+			"einsum equation %q: unexpected second \"->\" at position %d",
+			equation, len(lhs)+2+strings.Index(rhs, "->"),
+		)
+	}
+
+	offset := 0
+	for _, subscripts := range strings.Split(lhs, ",") {
+		term, err := parseEinsumTerm(equation, subscripts, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		eq.inputs = append(eq.inputs, term)
+		offset += len(subscripts) + 1
+	}
+
+	if len(eq.inputs) != numInputs {
+		return nil, fmt.Errorf(
+			"einsum equation %q has subscripts for %d inputs, got %d inputs",
+			equation, len(eq.inputs), numInputs,
+		)
+	}
+
+	// count occurrences of each label across inputs
+	counts := make(map[byte]int)
+	hasEllipsis := false
+	for _, term := range eq.inputs {
+		for _, label := range term.labels {
+			counts[label]++
+		}
+		hasEllipsis = hasEllipsis || term.ellipsis >= 0
+	}
+
+	if !explicit {
+		output := &einsumTerm{ellipsis: -1, offset: len(equation)}
+		if hasEllipsis {
+			output.ellipsis = 0
+		}
+
+		for label, count := range counts {
+			if count == 1 {
+				output.labels = append(output.labels, label)
+				output.offsets = append(output.offsets, len(equation))
+			}
+		}
+		sort.Slice(output.labels, func(i, j int) bool { return output.labels[i] < output.labels[j] })
+
+		eq.output = output
+		return eq, nil
+	}
+
+	output, err := parseEinsumTerm(equation, rhs, len(lhs)+2)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[byte]bool)
+	for i, label := range output.labels {
+		if seen[label] {
+			return nil, fmt.Errorf(
+				"einsum equation %q: output subscript %q at position %d is repeated",
+				equation, label, output.offsets[i],
+			)
+		}
+		seen[label] = true
+
+		if counts[label] == 0 {
+			return nil, fmt.Errorf(
+				"einsum equation %q: output subscript %q at position %d does not appear in any input",
+				equation, label, output.offsets[i],
+			)
+		}
+	}
+
+	eq.output = output
+	return eq, nil
+}
+
+// parseEinsumTerm parses subscripts of a single term located at
+// offset in the equation
+func parseEinsumTerm(equation, subscripts string, offset int) (*einsumTerm, error) {
+	term := &einsumTerm{ellipsis: -1, offset: offset}
+
+	for i := 0; i < len(subscripts); i++ {
+		c := subscripts[i]
+		switch {
+		case c == ' ':
+			continue
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			term.labels = append(term.labels, c)
+			term.offsets = append(term.offsets, offset+i)
+		case c == '.':
+			if !strings.HasPrefix(subscripts[i:], einsumEllipsis) {
+				return nil, fmt.Errorf(
+					"einsum equation %q: incomplete ellipsis at position %d", equation, offset+i,
+				)
+			}
+
+			if term.ellipsis >= 0 {
+				return nil, fmt.Errorf(
+					"einsum equation %q: second ellipsis in subscripts %q at position %d",
+					equation, subscripts, offset+i,
+				)
+			}
+
+			term.ellipsis = len(term.labels)
+			term.ellipsisOffset = offset + i
+			i += len(einsumEllipsis) - 1
+		default:
+			return nil, fmt.Errorf(
+				"einsum equation %q: invalid subscript %q at position %d, expected a letter or ellipsis",
+				equation, c, offset+i,
+			)
+		}
+	}
+
+	return term, nil
+}
+
+// shape validates input shapes against the equation and returns
+// output shape. Negative values denote unknown dimensions
+func (eq *einsumEquation) shape(shapes [][]int64) ([]int64, error) {
+	// dims maps each label to its dimension along with
+	// input index and offset of the subscript that bound it
+	type binding struct {
+		dim    int64
+		input  int
+		offset int
+	}
+	dims := make(map[byte]binding)
+
+	var broadcast []int64
+	hasBroadcast := false
+	for i, term := range eq.inputs {
+		shape := shapes[i]
+		numBroadcast := len(shape) - len(term.labels)
+
+		if term.ellipsis < 0 && numBroadcast != 0 {
+			return nil, fmt.Errorf(
+				"einsum equation %q: subscripts %q at position %d have %d dimensions, but input %d has shape %v",
+				eq.equation, term.String(), term.offset, len(term.labels), i, shape,
+			)
+		}
+
+		if term.ellipsis >= 0 && numBroadcast < 0 {
+			return nil, fmt.Errorf(
+				"einsum equation %q: subscripts %q at position %d have at least %d dimensions, but input %d has shape %v",
+				eq.equation, term.String(), term.offset, len(term.labels), i, shape,
+			)
+		}
+
+		for j, label := range term.labels {
+			// labels after ellipsis are shifted by broadcast dimensions
+			k := j
+			if term.ellipsis >= 0 && j >= term.ellipsis {
+				k += numBroadcast
+			}
+			dim := shape[k]
+
+			b, ok := dims[label]
+			switch {
+			case !ok || b.dim < 0:
+				dims[label] = binding{dim: dim, input: i, offset: term.offsets[j]}
+			case dim >= 0 && dim != b.dim:
+				return nil, fmt.Errorf(
+					"einsum equation %q: subscript %q at position %d has dimension %d in input %d, but it was bound to dimension %d at position %d in input %d",
+					eq.equation, label, term.offsets[j], dim, i, b.dim, b.offset, b.input,
+				)
+			}
+		}
+
+		if term.ellipsis >= 0 {
+			var err error
+			hasBroadcast = hasBroadcast || numBroadcast > 0
+			ellipsis := shape[term.ellipsis : term.ellipsis+numBroadcast]
+			if broadcast, err = broadcastShapes(broadcast, ellipsis); err != nil {
+				return nil, fmt.Errorf(
+					"einsum equation %q: ellipsis at position %d can't be broadcast for input %d: %w",
+					eq.equation, term.ellipsisOffset, i, err,
+				)
+			}
+		}
+	}
+
+	output := eq.output
+	if output.ellipsis < 0 && hasBroadcast {
+		return nil, fmt.Errorf(
+			"einsum equation %q: output subscripts %q at position %d need an ellipsis to keep broadcast dimensions %v",
+			eq.equation, output.String(), output.offset, broadcast,
+		)
+	}
+
+	shape := make([]int64, 0, len(output.labels)+len(broadcast))
+	for j, label := range output.labels {
+		if j == output.ellipsis {
+			shape = append(shape, broadcast...)
+		}
+		shape = append(shape, dims[label].dim)
+	}
+
+	if output.ellipsis == len(output.labels) {
+		shape = append(shape, broadcast...)
+	}
+
+	return shape, nil
+}
+
+// intermediate returns output subscripts for pairwise contraction of left
+// with i-th input, keeping labels that are needed by remaining inputs or
+// by the output
+func (eq *einsumEquation) intermediate(left *einsumTerm, i int) *einsumTerm {
+	needed := make(map[byte]bool)
+	for _, label := range eq.output.labels {
+		needed[label] = true
+	}
+
+	for _, term := range eq.inputs[i+1:] {
+		for _, label := range term.labels {
+			needed[label] = true
+		}
+	}
+
+	term := &einsumTerm{ellipsis: -1}
+	if left.ellipsis >= 0 || eq.inputs[i].ellipsis >= 0 {
+		term.ellipsis = 0
+	}
+
+	seen := make(map[byte]bool)
+	for _, label := range append(clone(left.labels), eq.inputs[i].labels...) {
+		if needed[label] && !seen[label] {
+			term.labels = append(term.labels, label)
+			seen[label] = true
+		}
+	}
+
+	return term
+}
@@ -0,0 +1,169 @@
+package tfutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEinsum(t *testing.T) {
+	tests := []struct {
+		equation  string
+		numInputs int
+		explicit  string
+	}{
+		{"ij,jk->ik", 2, "ij,jk->ik"},
+		{"ij,jk", 2, "ij,jk->ik"},
+		{"ba", 1, "ba->ab"},
+		{"ii", 1, "ii->"},
+		{"...ij,...jk", 2, "...ij,...jk->...ik"},
+		{"i...j->j...i", 1, "i...j->j...i"},
+		{"ij, jk -> ki", 2, "ij,jk->ki"},
+	}
+
+	for _, test := range tests {
+		eq, err := parseEinsum(test.equation, test.numInputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if eq.String() != test.explicit {
+			t.Fatalf("expected %q to parse as %q, got %q", test.equation, test.explicit, eq.String())
+		}
+	}
+
+	errors := []struct {
+		equation  string
+		numInputs int
+		message   string
+	}{
+		{"ij,jk->ik", 1, "subscripts for 2 inputs"},
+		{"i1,jk->ik", 2, "invalid subscript '1' at position 1"},
+		{"ij,jk->il", 2, "output subscript 'l' at position 8"},
+		{"ij,jk->ii", 2, "output subscript 'i' at position 8 is repeated"},
+		{"i..j->ij", 1, "incomplete ellipsis at position 1"},
+		{"......->...", 1, "second ellipsis"},
+		{"ij->i->j", 1, "second \"->\" at position 5"},
+	}
+
+	for _, test := range errors {
+		_, err := parseEinsum(test.equation, test.numInputs)
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Fatalf("expected %q to fail with %q, got %v", test.equation, test.message, err)
+		}
+	}
+}
+
+func TestEinsumShape(t *testing.T) {
+	eq, err := parseEinsum("...ij,jk->...ik", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shape, err := eq.shape([][]int64{{5, 1, 2, 3}, {3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(shape, []int64{5, 1, 2, 4}) {
+		t.Fatal("einsum shape does not match expected value")
+	}
+
+	shape, err = eq.shape([][]int64{{2, 3}, {3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(shape, []int64{2, 4}) {
+		t.Fatal("einsum shape does not match expected value")
+	}
+
+	_, err = eq.shape([][]int64{{2, 3}, {4, 4}})
+	if err == nil || !strings.Contains(err.Error(), "subscript 'j' at position 6 has dimension 4 in input 1") {
+		t.Fatal("expected einsum shape to fail for mismatched subscript, got", err)
+	}
+
+	_, err = eq.shape([][]int64{{3}, {3, 4}})
+	if err == nil || !strings.Contains(err.Error(), "at least 2 dimensions") {
+		t.Fatal("expected einsum shape to fail for insufficient rank, got", err)
+	}
+
+	eq, err = parseEinsum("...i,...i->i", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := eq.shape([][]int64{{2, 3}, {4, 3}}); err == nil || !strings.Contains(err.Error(), "ellipsis at position 5") {
+		t.Fatal("expected einsum shape to fail for incompatible ellipsis, got", err)
+	}
+
+	if _, err := eq.shape([][]int64{{2, 3}, {3}}); err == nil || !strings.Contains(err.Error(), "need an ellipsis") {
+		t.Fatal("expected einsum shape to fail for dropped broadcast dimensions, got", err)
+	}
+}
+
+func TestEinsum(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float64{1, 0, 0, 1, 1, 1}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := Einsum("ij,jk->ik", x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(z.value, []float64{4, 5, 10, 11}) || !equal(z.shape, []int{2, 2}) {
+		t.Fatal("einsum output does not match expected value")
+	}
+
+	trace, err := Einsum("ii", z)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(trace.value, []float64{15}) {
+		t.Fatal("einsum trace does not match expected value")
+	}
+
+	u, err := NewTensor([]float64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dot, err := Einsum("i,i->", u, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(dot.value, []float64{14}) || !equal(dot.shape, []int{1}) {
+		t.Fatal("einsum dot product does not match expected value")
+	}
+
+	transposed, err := Einsum("...ij->...ji", x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(transposed.value, []float64{1, 4, 2, 5, 3, 6}) || !equal(transposed.shape, []int{3, 2}) {
+		t.Fatal("einsum transpose does not match expected value")
+	}
+
+	// chained contraction of three inputs
+	w, err := Einsum("ij,jk,kl->il", x, y, z)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(w.value, []float64{66, 75, 150, 171}) || !equal(w.shape, []int{2, 2}) {
+		t.Fatal("einsum chained output does not match expected value")
+	}
+
+	if _, err := Einsum("ij,jk->ik", x, x); err == nil {
+		t.Fatal("expected einsum to fail for mismatched dimensions")
+	}
+}
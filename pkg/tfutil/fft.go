@@ -0,0 +1,294 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// FFT computes one dimensional discrete Fourier transform over the
+// innermost axis of input
+func FFT[T ComplexTypes](input *Tensor[T]) (*Tensor[T], error) {
+	return fft(input, 1, false)
+}
+
+// FFT2D computes two dimensional discrete Fourier transform over the
+// two innermost axes of input
+func FFT2D[T ComplexTypes](input *Tensor[T]) (*Tensor[T], error) {
+	return fft(input, 2, false)
+}
+
+// FFT3D computes three dimensional discrete Fourier transform over the
+// three innermost axes of input
+func FFT3D[T ComplexTypes](input *Tensor[T]) (*Tensor[T], error) {
+	return fft(input, 3, false)
+}
+
+// IFFT computes one dimensional inverse discrete Fourier transform over
+// the innermost axis of input
+func IFFT[T ComplexTypes](input *Tensor[T]) (*Tensor[T], error) {
+	return fft(input, 1, true)
+}
+
+// IFFT2D computes two dimensional inverse discrete Fourier transform over
+// the two innermost axes of input
+func IFFT2D[T ComplexTypes](input *Tensor[T]) (*Tensor[T], error) {
+	return fft(input, 2, true)
+}
+
+// IFFT3D computes three dimensional inverse discrete Fourier transform over
+// the three innermost axes of input
+func IFFT3D[T ComplexTypes](input *Tensor[T]) (*Tensor[T], error) {
+	return fft(input, 3, true)
+}
+
+// RFFT computes one dimensional discrete Fourier transform of real input
+// over its innermost axis. Output data type C needs to be complex64 for
+// float32 input and complex128 for float64 input. fftLength, if provided,
+// sets length of transform, for which input is cropped or zero padded,
+// and defaults to the length of innermost axis. Since transform of real
+// input is hermitian symmetric, only fftLength/2 + 1 unique components
+// are returned along innermost axis
+func RFFT[C ComplexTypes, F FloatTypes](input *Tensor[F], fftLength ...int) (*Tensor[C], error) {
+	return rfft[C](input, 1, fftLength)
+}
+
+// RFFT2D computes two dimensional discrete Fourier transform of real input
+// over its two innermost axes. See RFFT for details
+func RFFT2D[C ComplexTypes, F FloatTypes](input *Tensor[F], fftLength ...int) (*Tensor[C], error) {
+	return rfft[C](input, 2, fftLength)
+}
+
+// RFFT3D computes three dimensional discrete Fourier transform of real
+// input over its three innermost axes. See RFFT for details
+func RFFT3D[C ComplexTypes, F FloatTypes](input *Tensor[F], fftLength ...int) (*Tensor[C], error) {
+	return rfft[C](input, 3, fftLength)
+}
+
+// IRFFT computes one dimensional inverse of RFFT over the innermost axis
+// of input. Output data type F needs to be float32 for complex64 input
+// and float64 for complex128 input. fftLength, if provided, sets length of
+// real output and defaults to 2 * (n - 1), where n is the length of
+// innermost axis of input
+func IRFFT[F FloatTypes, C ComplexTypes](input *Tensor[C], fftLength ...int) (*Tensor[F], error) {
+	return irfft[F](input, 1, fftLength)
+}
+
+// IRFFT2D computes two dimensional inverse of RFFT2D over the two
+// innermost axes of input. See IRFFT for details
+func IRFFT2D[F FloatTypes, C ComplexTypes](input *Tensor[C], fftLength ...int) (*Tensor[F], error) {
+	return irfft[F](input, 2, fftLength)
+}
+
+// IRFFT3D computes three dimensional inverse of RFFT3D over the three
+// innermost axes of input. See IRFFT for details
+func IRFFT3D[F FloatTypes, C ComplexTypes](input *Tensor[C], fftLength ...int) (*Tensor[F], error) {
+	return irfft[F](input, 3, fftLength)
+}
+
+// FFTShift rolls input along axes by half of their lengths, which moves
+// zero frequency component to the center of spectrum. Default is all axes
+func FFTShift[T PrimitiveTypes](input *Tensor[T], axes ...int) (*Tensor[T], error) {
+	return fftShift(input, axes, false)
+}
+
+// IFFTShift is the inverse of FFTShift. It differs from FFTShift only
+// for axes of odd length
+func IFFTShift[T PrimitiveTypes](input *Tensor[T], axes ...int) (*Tensor[T], error) {
+	return fftShift(input, axes, true)
+}
+
+// fft computes forward or inverse transform over innermost dims axes
+func fft[T ComplexTypes](input *Tensor[T], dims int, inverse bool) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(input.shape) < dims {
+		return nil, fmt.Errorf("input needs to have rank at least %d, got shape %v", dims, input.shape)
+	}
+
+	transforms := [][2]func(*op.Scope, tf.Output) tf.Output{
+		{op.FFT, op.IFFT},
+		{op.FFT2D, op.IFFT2D},
+		{op.FFT3D, op.IFFT3D},
+	}
+
+	transform := transforms[dims-1][0]
+	if inverse {
+		transform = transforms[dims-1][1]
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			return transform(scope, outputs[0]), nil
+		},
+		input,
+	)
+}
+
+// rfft computes transform of real input over innermost dims axes
+func rfft[C ComplexTypes, F FloatTypes](input *Tensor[F], dims int, fftLength []int) (*Tensor[C], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(input.shape) < dims {
+		return nil, fmt.Errorf("input needs to have rank at least %d, got shape %v", dims, input.shape)
+	}
+
+	if err := checkComplexPair[C, F](); err != nil {
+		return nil, err
+	}
+
+	if len(fftLength) == 0 {
+		fftLength = clone(input.shape[len(input.shape)-dims:])
+	}
+
+	if err := checkFFTLength(fftLength, dims); err != nil {
+		return nil, err
+	}
+
+	dataType, err := dataTypeOf[C]()
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	length := op.Const(root.SubScope("fftLength"), castToInt32(fftLength))
+
+	var Output tf.Output
+	switch dims {
+	case 1:
+		Output = op.RFFT(root, X, length, op.RFFTTcomplex(dataType))
+	case 2:
+		Output = op.RFFT2D(root, X, length, op.RFFT2DTcomplex(dataType))
+	default:
+		Output = op.RFFT3D(root, X, length, op.RFFT3DTcomplex(dataType))
+	}
+
+	return runOutput[C](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// irfft computes inverse transform to real output over innermost dims axes
+func irfft[F FloatTypes, C ComplexTypes](input *Tensor[C], dims int, fftLength []int) (*Tensor[F], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(input.shape) < dims {
+		return nil, fmt.Errorf("input needs to have rank at least %d, got shape %v", dims, input.shape)
+	}
+
+	if err := checkComplexPair[C, F](); err != nil {
+		return nil, err
+	}
+
+	if len(fftLength) == 0 {
+		fftLength = clone(input.shape[len(input.shape)-dims:])
+		fftLength[dims-1] = 2 * (fftLength[dims-1] - 1)
+	}
+
+	if err := checkFFTLength(fftLength, dims); err != nil {
+		return nil, err
+	}
+
+	dataType, err := dataTypeOf[F]()
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	length := op.Const(root.SubScope("fftLength"), castToInt32(fftLength))
+
+	var Output tf.Output
+	switch dims {
+	case 1:
+		Output = op.IRFFT(root, X, length, op.IRFFTTreal(dataType))
+	case 2:
+		Output = op.IRFFT2D(root, X, length, op.IRFFT2DTreal(dataType))
+	default:
+		Output = op.IRFFT3D(root, X, length, op.IRFFT3DTreal(dataType))
+	}
+
+	return runOutput[F](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// checkFFTLength checks that fftLength has one positive value per
+// transform dimension
+func checkFFTLength(fftLength []int, dims int) error {
+	if len(fftLength) != dims {
+		return fmt.Errorf("fftLength needs %d values, got %v", dims, fftLength)
+	}
+
+	for _, n := range fftLength {
+		if n <= 0 {
+			return fmt.Errorf("fftLength needs positive values, got %v", fftLength)
+		}
+	}
+
+	return nil
+}
+
+// fftShift rolls input along axes by half of their lengths, rounded
+// down for forward shift and up for inverse shift
+func fftShift[T PrimitiveTypes](input *Tensor[T], axes []int, inverse bool) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(axes) == 0 {
+		axes = make([]int, len(input.shape))
+		for i := range axes {
+			axes[i] = i
+		}
+	}
+
+	axes, _, err := splitAxes(axes, len(input.shape))
+	if err != nil {
+		return nil, fmt.Errorf("invalid axes: %w", err)
+	}
+
+	shifts := make([]int, len(input.shape))
+	for _, axis := range axes {
+		n := input.shape[axis]
+		shifts[axis] = n / 2
+		if inverse {
+			shifts[axis] = n - n/2
+		}
+	}
+
+	// element at index i along an axis moves to (i + shift) mod n
+	values := make([]T, len(input.value))
+	index := make([]int, len(input.shape))
+	for _, v := range input.value {
+		offset := 0
+		for d, n := range input.shape {
+			offset = offset*n + (index[d]+shifts[d])%n
+		}
+		values[offset] = v
+
+		for d := len(index) - 1; d >= 0; d-- {
+			index[d]++
+			if index[d] < input.shape[d] {
+				break
+			}
+			index[d] = 0
+		}
+	}
+
+	return NewTensor(values, clone(input.shape)...)
+}
@@ -0,0 +1,131 @@
+package tfutil
+
+import (
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	x, err := NewTensor([]complex128{1, 2, 3, 4}, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := FFT(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(y.value, []complex128{10, -2 + 2i, -2, -2 - 2i}, 1e-12) || !equal(y.shape, []int{1, 4}) {
+		t.Fatal("fft output does not match expected value")
+	}
+
+	z, err := IFFT(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(z.value, x.value, 1e-12) {
+		t.Fatal("ifft output does not match input")
+	}
+}
+
+func TestFFT2D(t *testing.T) {
+	x, err := NewTensor([]complex64{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := FFT2D(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(y.value, []complex64{10, -2, -4, 0}, 1e-5) {
+		t.Fatal("fft2d output does not match expected value")
+	}
+
+	z, err := IFFT2D(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(z.value, x.value, 1e-5) {
+		t.Fatal("ifft2d output does not match input")
+	}
+
+	if _, err := FFT3D(x); err == nil {
+		t.Fatal("expected fft3d to fail for input of rank 2")
+	}
+}
+
+func TestRFFT(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := RFFT[complex128](x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(y.value, []complex128{10, -2 + 2i, -2}, 1e-12) || !equal(y.shape, []int{3}) {
+		t.Fatal("rfft output does not match expected value")
+	}
+
+	z, err := IRFFT[float64](y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(z.value, x.value, 1e-12) || !equal(z.shape, []int{4}) {
+		t.Fatal("irfft output does not match input")
+	}
+
+	if _, err := RFFT[complex64](x); err == nil {
+		t.Fatal("expected rfft to fail for mismatched precision")
+	}
+
+	if _, err := RFFT[complex128](x, 4, 4); err == nil {
+		t.Fatal("expected rfft to fail for invalid fft length")
+	}
+}
+
+func TestFFTShift(t *testing.T) {
+	x, err := NewTensor([]int32{0, 1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := FFTShift(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.value, []int32{3, 4, 0, 1, 2}) {
+		t.Fatal("fftshift output does not match expected value")
+	}
+
+	z, err := IFFTShift(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(z.value, x.value) {
+		t.Fatal("ifftshift output does not match input")
+	}
+
+	m, err := NewTensor([]int32{0, 1, 2, 3, 4, 5}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := FFTShift(m, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(n.value, []int32{2, 0, 1, 5, 3, 4}) || !equal(n.shape, []int{2, 3}) {
+		t.Fatal("fftshift output along axis does not match expected value")
+	}
+}
@@ -21,73 +21,16 @@ func MatrixInverse[T PrimitiveTypes](input *Tensor[T]) (*Tensor[T], error) {
 	}
 }
 
-// MatrixMultiply performs matrix multiplication
-func MatrixMultiply[T PrimitiveTypes](x, y *Tensor[T]) (*Tensor[T], error) {
+// MatrixMultiply performs matrix multiplication of innermost matrices
+// of inputs of shapes [..., M, K] and [..., K, N]. Batch dimensions,
+// if any, are broadcast. Options can be provided to transpose or
+// adjoint either input prior to multiplication
+func MatrixMultiply[T PrimitiveTypes](x, y *Tensor[T], options ...MatMulOption) (*Tensor[T], error) {
 	return Apply(
-		MatMulOp, x, y,
+		MatrixMultiplyOp(options...), x, y,
 	)
 }
 
-// Cast casts input tensor of data type T to a new tensor
-// of data type S
-func Cast[S, T PrimitiveTypes](input *Tensor[T]) (*Tensor[S], error) {
-	output := &Tensor[S]{
-		value: make([]S, 1),
-		shape: make([]int, 1),
-	}
-
-	x, err := input.Marshal()
-	if err != nil {
-		return nil, fmt.Errorf("failed to form tensor for source input: %w", err)
-	}
-
-	y, err := output.Marshal()
-	if err != nil {
-		return nil, fmt.Errorf("failed to form tensor for source output: %w", err)
-	}
-
-	root := op.NewScope()
-	Output := op.Cast(root, op.Const(root, x), y.DataType())
-
-	graph, err := root.Finalize()
-	if err != nil {
-		return nil, fmt.Errorf("failed to import graph: %w", err)
-	}
-
-	fetches := []tf.Output{
-		Output,
-	}
-
-	sess, err := tf.NewSession(
-		graph,
-		&tf.SessionOptions{},
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create new tf session: %w", err)
-	}
-	defer func(sess *tf.Session) {
-		err := sess.Close()
-		if err != nil {
-			panic(err)
-		}
-	}(sess)
-
-	out, err := sess.Run(nil, fetches, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to run tf session: %w", err)
-	}
-
-	if len(out) != 1 {
-		return nil, fmt.Errorf("expected session run output to have length 1, got %d", len(out))
-	}
-
-	if err := output.Unmarshal(out[0]); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal output: %w", err)
-	}
-
-	return output, nil
-}
-
 // Transpose transposes a tensor. perm refers to the new order
 // of dimensions. For instance, if input tensor is 2x3 and perm
 // for a standard transpose should be [1, 0] referring to a shape
@@ -290,7 +233,7 @@ func Imag32(complexT *Tensor[complex64]) *Tensor[float32] {
 
 // Abs returns absolute valued tensor such that each element
 // of output is absolute value of each of the complex values
-// of input
+// of input. See AbsOf for complex64 input
 func Abs(complexT *Tensor[complex128]) *Tensor[float64] {
 	f := func(i int) float64 {
 		return cmplx.Abs(complexT.value[i])
@@ -299,3 +242,92 @@ func Abs(complexT *Tensor[complex128]) *Tensor[float64] {
 	absT, _ := NewTensorFromFunc(f, complexT.shape...)
 	return absT
 }
+
+// Conj returns complex conjugate of each element of input tensor
+func Conj[T ComplexTypes](complexT *Tensor[T]) *Tensor[T] {
+	f := func(i int) T {
+		return T(cmplx.Conj(complex128(complexT.value[i])))
+	}
+
+	conjT, _ := NewTensorFromFunc(f, complexT.shape...)
+	return conjT
+}
+
+// ComplexExp returns complex exponential, exp(z), of each element z
+// of input tensor
+func ComplexExp[T ComplexTypes](complexT *Tensor[T]) *Tensor[T] {
+	f := func(i int) T {
+		return T(cmplx.Exp(complex128(complexT.value[i])))
+	}
+
+	expT, _ := NewTensorFromFunc(f, complexT.shape...)
+	return expT
+}
+
+// AbsOf returns absolute values of complex tensor as a new tensor of
+// float type F, which needs to match precision of C, i.e., float32 for
+// complex64 and float64 for complex128. F can't be inferred from C and
+// is hence the first type parameter, e.g., AbsOf[float32](x)
+func AbsOf[F FloatTypes, C ComplexTypes](complexT *Tensor[C]) (*Tensor[F], error) {
+	if complexT == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if err := checkComplexPair[C, F](); err != nil {
+		return nil, err
+	}
+
+	f := func(i int) F {
+		return F(cmplx.Abs(complex128(complexT.value[i])))
+	}
+
+	return NewTensorFromFunc(f, complexT.shape...)
+}
+
+// AngleOf returns phase angles in radians, in the range [-Pi, Pi], of
+// complex tensor as a new tensor of float type F, e.g.,
+// AngleOf[float32](x) for x of complex64 type. See AbsOf
+func AngleOf[F FloatTypes, C ComplexTypes](complexT *Tensor[C]) (*Tensor[F], error) {
+	if complexT == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if err := checkComplexPair[C, F](); err != nil {
+		return nil, err
+	}
+
+	f := func(i int) F {
+		return F(cmplx.Phase(complex128(complexT.value[i])))
+	}
+
+	return NewTensorFromFunc(f, complexT.shape...)
+}
+
+// PolarOf packs input magnitude and phase angle in radians to a tensor
+// of complex type C, i.e., each element is magnitude * exp(i * phase),
+// e.g., PolarOf[complex64](magnitude, phase) for inputs of float32 type.
+// See AbsOf
+func PolarOf[C ComplexTypes, F FloatTypes](magnitudeT, phaseT *Tensor[F]) (*Tensor[C], error) {
+	if magnitudeT == nil || phaseT == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if err := checkComplexPair[C, F](); err != nil {
+		return nil, err
+	}
+
+	if !equal(magnitudeT.shape, phaseT.shape) {
+		return nil, fmt.Errorf("input tensor shapes do not match")
+	}
+
+	if len(magnitudeT.value) != len(phaseT.value) {
+		return nil, fmt.Errorf("input tensor value lengths do not match")
+	}
+
+	c := make([]C, len(magnitudeT.value))
+	for i := range c {
+		c[i] = C(cmplx.Rect(float64(magnitudeT.value[i]), float64(phaseT.value[i])))
+	}
+
+	return NewTensor(c, magnitudeT.shape...)
+}
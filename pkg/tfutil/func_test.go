@@ -3,6 +3,7 @@ package tfutil
 import (
 	"bytes"
 	"encoding/json"
+	"math"
 	"math/rand"
 	"testing"
 )
@@ -125,3 +126,127 @@ func TestCast(t *testing.T) {
 		}
 	}
 }
+
+func TestComplexAbsAngle(t *testing.T) {
+	x, err := NewTensor([]complex64{complex(3, 4), complex(0, -2), complex(-1, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	abs, err := AbsOf[float32](x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(abs.value, []float32{5, 2, 1}) {
+		t.Fatal("abs output does not match expected value")
+	}
+
+	angle, err := AngleOf[float32](x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range []float64{math.Atan2(4, 3), -math.Pi / 2, math.Pi} {
+		if math.Abs(float64(angle.value[i])-v) > 1e-6 {
+			t.Fatal("angle output does not match expected value")
+		}
+	}
+
+	y, err := NewTensor([]complex128{complex(3, 4), complex(0, -2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	abs64, err := AbsOf[float64](y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(Abs(y).value, abs64.value) {
+		t.Fatal("abs and generic abs outputs do not match")
+	}
+
+	if _, err := AbsOf[float32](y); err == nil {
+		t.Fatal("expected abs to fail for float32 output of complex128 input")
+	}
+
+	if _, err := AngleOf[float64](x); err == nil {
+		t.Fatal("expected angle to fail for float64 output of complex64 input")
+	}
+}
+
+func TestComplexPolar(t *testing.T) {
+	magnitude, err := NewTensor([]float64{2, 1, 3, 1}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	phase, err := NewTensor([]float64{0, math.Pi / 2, math.Pi, -math.Pi / 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := PolarOf[complex128](magnitude, phase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(c.shape, []int{2, 2}) {
+		t.Fatal("polar output shape does not match expected shape")
+	}
+
+	// round trip via abs and angle
+	abs, err := AbsOf[float64](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	angle, err := AngleOf[float64](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range c.value {
+		if math.Abs(abs.value[i]-magnitude.value[i]) > 1e-12 ||
+			math.Abs(angle.value[i]-phase.value[i]) > 1e-12 {
+			t.Fatal("polar output does not match expected value")
+		}
+	}
+
+	other, err := NewTensor([]float64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PolarOf[complex128](magnitude, other); err == nil {
+		t.Fatal("expected polar to fail for mismatched shapes")
+	}
+
+	if _, err := PolarOf[complex64](magnitude, phase); err == nil {
+		t.Fatal("expected polar to fail for complex64 output of float64 inputs")
+	}
+}
+
+func TestComplexConjExp(t *testing.T) {
+	x, err := NewTensor([]complex64{complex(1, 2), complex(-3, -4)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(Conj(x).value, []complex64{complex(1, -2), complex(-3, 4)}) {
+		t.Fatal("conj output does not match expected value")
+	}
+
+	y, err := NewTensor([]complex128{0, complex(0, math.Pi), complex(1, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z := ComplexExp(y)
+	for i, v := range []complex128{1, -1, complex(math.E, 0)} {
+		if math.Abs(real(z.value[i])-real(v)) > 1e-12 || math.Abs(imag(z.value[i])-imag(v)) > 1e-12 {
+			t.Fatal("exp output does not match expected value")
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// gradientGraph is a graph of an operator applied over placeholders
+// for input tensors
+type gradientGraph struct {
+	root   *op.Scope
+	feeds  map[tf.Output]*tf.Tensor
+	inputs []tf.Output
+	output tf.Output
+}
+
+// newGradientGraph applies operator over placeholders for inputs
+func newGradientGraph[T FloatTypes](operator Operator, inputs []*Tensor[T]) (*gradientGraph, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("at least one input is needed")
+	}
+
+	g := &gradientGraph{
+		root:   op.NewScope(),
+		feeds:  make(map[tf.Output]*tf.Tensor),
+		inputs: make([]tf.Output, len(inputs)),
+	}
+
+	for i, input := range inputs {
+		if input == nil {
+			return nil, fmt.Errorf("input %d can't be nil", i)
+		}
+
+		x, err := input.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+		}
+
+		g.inputs[i] = placeholder(g.root, fmt.Sprintf("T%d", i), x)
+		g.feeds[g.inputs[i]] = x
+	}
+
+	output, err := operator(g.root.SubScope("operator"), g.inputs...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid operator: %w", err)
+	}
+	g.output = output
+
+	return g, nil
+}
+
+// wrt returns placeholders for inputs at indices, defaulting to all inputs
+func (g *gradientGraph) wrt(indices []int) ([]tf.Output, error) {
+	if len(indices) == 0 {
+		return g.inputs, nil
+	}
+
+	outputs := make([]tf.Output, len(indices))
+	for i, index := range indices {
+		if index < 0 || index >= len(g.inputs) {
+			return nil, fmt.Errorf("input index %d out of range for %d inputs", index, len(g.inputs))
+		}
+		outputs[i] = g.inputs[index]
+	}
+
+	return outputs, nil
+}
+
+// gradients adds gradient ops of y with respect to x, optionally seeded
+// with upstream gradient dy. Gradients with respect to x that y does not
+// depend on are zeros, as for unconnected gradients set to zero in
+// tensorflow python API
+func gradients(scope *op.Scope, y, x []tf.Output, dy ...tf.Output) ([]tf.Output, error) {
+	grads := op.Gradients(scope, y, x, dy...)
+	if err := scope.Err(); err != nil {
+		return nil, fmt.Errorf("failed to add gradients: %w", err)
+	}
+
+	for i, grad := range grads {
+		if grad.Op == nil {
+			grads[i] = op.ZerosLike(scope.SubScope("zeros"), x[i])
+		}
+	}
+
+	return grads, nil
+}
+
+// Gradient computes gradients of output of operator applied over inputs
+// with respect to inputs at indices wrt, which default to all inputs.
+// For an output that is not a scalar, gradients are those of the sum of
+// its elements. Each gradient has the shape of its input and is zero for
+// an input that output does not depend on. Gradients are
+// computed using gradient functions registered with tensorflow C++ API,
+// so all ops used by the operator need to have one
+func Gradient[T FloatTypes](operator Operator, inputs []*Tensor[T], wrt ...int) ([]*Tensor[T], error) {
+	return gradient(operator, nil, inputs, wrt)
+}
+
+// GradientWithSeed computes gradients of output of operator applied over
+// inputs with respect to inputs at indices wrt, given upstream gradient
+// seed of output, i.e., vector-Jacobian product of seed with Jacobian of
+// output. seed needs to have the same number of elements as output.
+// See Gradient for details
+func GradientWithSeed[T FloatTypes](operator Operator, seed *Tensor[T], inputs []*Tensor[T], wrt ...int) ([]*Tensor[T], error) {
+	if seed == nil {
+		return nil, fmt.Errorf("seed can't be nil")
+	}
+
+	return gradient(operator, seed, inputs, wrt)
+}
+
+// gradient computes gradients optionally seeded with upstream gradient
+func gradient[T FloatTypes](operator Operator, seed *Tensor[T], inputs []*Tensor[T], wrt []int) ([]*Tensor[T], error) {
+	g, err := newGradientGraph(operator, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := g.wrt(wrt)
+	if err != nil {
+		return nil, err
+	}
+
+	var dy []tf.Output
+	if seed != nil {
+		if shape, err := g.output.Shape().ToSlice(); err == nil {
+			n := 1
+			for _, dim := range shape {
+				n *= int(dim)
+			}
+
+			if n >= 0 && n != len(seed.value) {
+				return nil, fmt.Errorf("seed of shape %v does not match output of shape %v", seed.shape, shape)
+			}
+		}
+
+		s, err := seed.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+		}
+
+		// seed is reshaped to output shape, which also
+		// covers outputs that are scalars
+		S := placeholder(g.root, "seed", s)
+		g.feeds[S] = s
+		dy = append(dy, op.Reshape(g.root, S, op.Shape(g.root, g.output)))
+	}
+
+	grads, err := gradients(g.root.SubScope("gradients"), []tf.Output{g.output}, x, dy...)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := runSession(g.root, g.feeds, grads...)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*Tensor[T], len(out))
+	for i := range out {
+		if outputs[i], err = fromTfTensor[T](out[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return outputs, nil
+}
+
+// Jacobian computes Jacobian of output of operator applied over inputs
+// with respect to input at index wrt. Output has shape of operator output
+// followed by shape of input, i.e., element [i..., j...] is derivative of
+// output element i with respect to input element j. A scalar operator
+// output contributes no dimensions. See Gradient for details
+func Jacobian[T FloatTypes](operator Operator, inputs []*Tensor[T], wrt int) (*Tensor[T], error) {
+	g, err := newGradientGraph(operator, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := g.wrt([]int{wrt})
+	if err != nil {
+		return nil, err
+	}
+
+	// seed is fed as a flat vector and reshaped to output shape,
+	// so that rows of Jacobian are obtained via one-hot seeds
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	S := op.Placeholder(g.root.SubScope("seed"), dataType, op.PlaceholderShape(tf.MakeShape(-1)))
+	dy := op.Reshape(g.root, S, op.Shape(g.root, g.output))
+
+	grads, err := gradients(g.root.SubScope("gradients"), []tf.Output{g.output}, x, dy)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := g.root.Finalize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to import graph: %w", err)
+	}
+
+	sess, err := tf.NewSession(
+		graph,
+		&tf.SessionOptions{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new tf session: %w", err)
+	}
+
+	defer func(sess *tf.Session) {
+		err := sess.Close()
+		if err != nil {
+			panic(err)
+		}
+	}(sess)
+
+	// output is evaluated first to get its shape
+	out, err := sess.Run(g.feeds, []tf.Output{g.output}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run tf session: %w", err)
+	}
+
+	outShape := out[0].Shape()
+	n := 1
+	for _, dim := range outShape {
+		n *= int(dim)
+	}
+
+	input := inputs[wrt]
+	values := make([]T, 0, n*len(input.value))
+	oneHot := make([]T, n)
+	for i := 0; i < n; i++ {
+		oneHot[i] = 1
+		s, err := tf.NewTensor(oneHot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+		}
+		oneHot[i] = 0
+
+		g.feeds[S] = s
+		out, err := sess.Run(g.feeds, grads, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run tf session: %w", err)
+		}
+
+		row, err := fromTfTensor[T](out[0])
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, row.value...)
+	}
+
+	shape := make([]int, 0, len(outShape)+len(input.shape))
+	for _, dim := range outShape {
+		shape = append(shape, int(dim))
+	}
+
+	return NewTensor(values, append(shape, input.shape...)...)
+}
+
+// HessianVectorProduct computes product of Hessian of output of operator
+// applied over inputs with respect to input at index wrt with vector of
+// shape of that input, without forming the Hessian. For an output that is
+// not a scalar, Hessian is that of the sum of its elements. Output has
+// shape of the input. See Gradient for details
+func HessianVectorProduct[T FloatTypes](operator Operator, inputs []*Tensor[T], wrt int, vector *Tensor[T]) (*Tensor[T], error) {
+	if vector == nil {
+		return nil, fmt.Errorf("vector can't be nil")
+	}
+
+	g, err := newGradientGraph(operator, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := g.wrt([]int{wrt})
+	if err != nil {
+		return nil, err
+	}
+
+	if !equal(vector.shape, inputs[wrt].shape) {
+		return nil, fmt.Errorf("vector of shape %v does not match input of shape %v", vector.shape, inputs[wrt].shape)
+	}
+
+	v, err := vector.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	V := placeholder(g.root, "vector", v)
+	g.feeds[V] = v
+
+	grads, err := gradients(g.root.SubScope("gradients"), []tf.Output{g.output}, x)
+	if err != nil {
+		return nil, err
+	}
+
+	// gradient of gradient seeded with vector is Hessian
+	// transpose times vector, which is Hessian times vector
+	// since Hessian is symmetric
+	hvp, err := gradients(g.root.SubScope("hessian"), grads, x, V)
+	if err != nil {
+		return nil, err
+	}
+
+	return runOutput[T](g.root, g.feeds, hvp[0])
+}
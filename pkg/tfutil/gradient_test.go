@@ -0,0 +1,170 @@
+package tfutil
+
+import (
+	"math"
+	"testing"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// squareSumOp computes sum of squares of first input
+var squareSumOp = func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+	return op.Sum(scope, op.Square(scope, outputs[0]), op.Const(scope.SubScope("axes"), []int32{0})), nil
+}
+
+// linearSumOp computes sum of first input scaled by 3
+var linearSumOp = func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+	scaled := op.Mul(scope, op.Const(scope.SubScope("scale"), 3.0), outputs[0])
+	return op.Sum(scope, scaled, op.Const(scope.SubScope("axes"), []int32{0})), nil
+}
+
+func TestGradient(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float64{5, 6, 7, 8}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grads, err := Gradient(MatMulOp, []*Tensor[float64]{x, y})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(grads) != 2 {
+		t.Fatalf("expected 2 gradients, got %d", len(grads))
+	}
+
+	// d/dx sum(x @ y) = ones @ y^T
+	if !equal(grads[0].value, []float64{11, 15, 11, 15}) || !equal(grads[0].shape, []int{2, 2}) {
+		t.Fatal("gradient wrt x does not match expected value")
+	}
+
+	// d/dy sum(x @ y) = x^T @ ones
+	if !equal(grads[1].value, []float64{4, 4, 6, 6}) {
+		t.Fatal("gradient wrt y does not match expected value")
+	}
+
+	seed, err := NewTensor([]float64{1, 0, 0, 0}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seeded, err := GradientWithSeed(MatMulOp, seed, []*Tensor[float64]{x, y}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(seeded[0].value, []float64{1, 0, 2, 0}) {
+		t.Fatal("seeded gradient does not match expected value")
+	}
+
+	// output does not depend on y
+	unconnected, err := Gradient(squareSumOp, []*Tensor[float64]{x, y})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(unconnected[1].value, []float64{0, 0, 0, 0}) || !equal(unconnected[1].shape, []int{2, 2}) {
+		t.Fatal("gradient wrt unconnected input is not zero")
+	}
+
+	if _, err := Gradient(MatMulOp, []*Tensor[float64]{x, y}, 2); err == nil {
+		t.Fatal("expected gradient to fail for out of range input index")
+	}
+
+	badSeed, err := NewTensor([]float64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GradientWithSeed(MatMulOp, badSeed, []*Tensor[float64]{x, y}); err == nil {
+		t.Fatal("expected gradient to fail for seed of mismatched shape")
+	}
+}
+
+func TestJacobian(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float64{5, 6, 7, 8}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jacobian, err := Jacobian(MatMulOp, []*Tensor[float64]{x, y}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(jacobian.shape, []int{2, 2, 2, 2}) {
+		t.Fatalf("expected jacobian of shape [2 2 2 2], got %v", jacobian.shape)
+	}
+
+	// d(x @ y)[i, j] / dx[k, l] = delta(i, k) * y[l, j]
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			for k := 0; k < 2; k++ {
+				for l := 0; l < 2; l++ {
+					expected := 0.0
+					if i == k {
+						expected = y.value[l*2+j]
+					}
+
+					if got := jacobian.value[((i*2+j)*2+k)*2+l]; got != expected {
+						t.Fatalf("jacobian at [%d %d %d %d] expected %v, got %v", i, j, k, l, expected, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestHessianVectorProduct(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewTensor([]float64{1, -1, 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// hessian of sum of squares is 2 * identity
+	hvp, err := HessianVectorProduct(squareSumOp, []*Tensor[float64]{x}, 0, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []float64{2, -2, 1} {
+		if math.Abs(hvp.value[i]-expected) > 1e-12 {
+			t.Fatalf("hessian vector product at %d expected %v, got %v", i, expected, hvp.value[i])
+		}
+	}
+
+	// hessian of a linear function is zero
+	linear, err := HessianVectorProduct(linearSumOp, []*Tensor[float64]{x}, 0, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(linear.value, []float64{0, 0, 0}) {
+		t.Fatal("hessian vector product of linear function is not zero:", linear.value)
+	}
+
+	w, err := NewTensor([]float64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := HessianVectorProduct(squareSumOp, []*Tensor[float64]{x}, 0, w); err == nil {
+		t.Fatal("expected hessian vector product to fail for vector of mismatched shape")
+	}
+}
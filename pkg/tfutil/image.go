@@ -0,0 +1,495 @@
+package tfutil
+
+import (
+	"fmt"
+	"math"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// ResizeOption configures image resizing
+type ResizeOption func(*resizeOptions)
+
+type resizeOptions struct {
+	alignCorners     bool
+	halfPixelCenters bool
+}
+
+// ResizeAlignCorners sets alignment of centers of corner pixels of input
+// and output, which needs half pixel centers to be disabled
+func ResizeAlignCorners(value bool) ResizeOption {
+	return func(o *resizeOptions) {
+		o.alignCorners = value
+	}
+}
+
+// ResizeHalfPixelCenters sets sampling at pixel centers offset by half a
+// pixel. Default is true, which matches resizing in tensorflow 2
+func ResizeHalfPixelCenters(value bool) ResizeOption {
+	return func(o *resizeOptions) {
+		o.halfPixelCenters = value
+	}
+}
+
+func newResizeOptions(options []ResizeOption) (*resizeOptions, error) {
+	o := &resizeOptions{halfPixelCenters: true}
+	for _, option := range options {
+		option(o)
+	}
+
+	if o.alignCorners && o.halfPixelCenters {
+		return nil, fmt.Errorf("align corners and half pixel centers can't both be set")
+	}
+
+	return o, nil
+}
+
+// CropAndResizeOption configures CropAndResize
+type CropAndResizeOption func(*cropAndResizeOptions)
+
+type cropAndResizeOptions struct {
+	nearest       bool
+	extrapolation float32
+}
+
+// CropAndResizeNearest sets nearest neighbour sampling instead of default
+// bilinear sampling
+func CropAndResizeNearest(value bool) CropAndResizeOption {
+	return func(o *cropAndResizeOptions) {
+		o.nearest = value
+	}
+}
+
+// CropAndResizeExtrapolation sets value of output pixels sampled outside
+// of image. Default is 0
+func CropAndResizeExtrapolation(value float32) CropAndResizeOption {
+	return func(o *cropAndResizeOptions) {
+		o.extrapolation = value
+	}
+}
+
+// DecodeImage decodes a PNG, JPEG, GIF or BMP encoded image into a tensor
+// of shape [height, width, channels]. channels sets number of color
+// channels of output, such as 1 for grayscale or 3 for RGB, with 0 keeping
+// channels of the encoded image. GIF images are decoded to 3 channels
+// and only the first frame of an animated GIF is decoded
+func DecodeImage(contents []byte, channels int) (*Tensor[uint8], error) {
+	switch channels {
+	case 0, 1, 3, 4:
+	default:
+		return nil, fmt.Errorf("channels needs to be 0, 1, 3 or 4, got %d", channels)
+	}
+
+	x, err := tf.NewTensor(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	Output := op.DecodeImage(
+		root,
+		X,
+		op.DecodeImageChannels(int64(channels)),
+		op.DecodeImageExpandAnimations(false),
+	)
+
+	return runOutput[uint8](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// EncodePng encodes image of shape [height, width, channels] with 1 to 4
+// channels as PNG. compression ranges from 0 for none to 9 for best, with
+// -1 for default compression
+func EncodePng(image *Tensor[uint8], compression int) ([]byte, error) {
+	if compression < -1 || compression > 9 {
+		return nil, fmt.Errorf("compression needs to be in [-1, 9], got %d", compression)
+	}
+
+	if err := checkImage(image, 1, 2, 3, 4); err != nil {
+		return nil, err
+	}
+
+	return encodeImage(image, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.EncodePng(scope, x, op.EncodePngCompression(int64(compression)))
+	})
+}
+
+// EncodeJpeg encodes image of shape [height, width, channels] with 1 or 3
+// channels as JPEG with quality ranging from 0 to 100
+func EncodeJpeg(image *Tensor[uint8], quality int) ([]byte, error) {
+	if quality < 0 || quality > 100 {
+		return nil, fmt.Errorf("quality needs to be in [0, 100], got %d", quality)
+	}
+
+	if err := checkImage(image, 1, 3); err != nil {
+		return nil, err
+	}
+
+	return encodeImage(image, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.EncodeJpeg(scope, x, op.EncodeJpegQuality(int64(quality)))
+	})
+}
+
+// ResizeBilinear resizes images of shape [height, width, channels] or
+// [batch, height, width, channels] to height and width using bilinear
+// interpolation. Images of data type uint32 or uint64 are not supported
+func ResizeBilinear[T RealTypes](images *Tensor[T], height, width int, options ...ResizeOption) (*Tensor[float32], error) {
+	o, err := newResizeOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return resize[T, float32](images, height, width, func(scope *op.Scope, x, size tf.Output) tf.Output {
+		return op.ResizeBilinear(
+			scope,
+			x,
+			size,
+			op.ResizeBilinearAlignCorners(o.alignCorners),
+			op.ResizeBilinearHalfPixelCenters(o.halfPixelCenters),
+		)
+	})
+}
+
+// ResizeNearest resizes images to height and width picking nearest
+// pixels, which retains data type of images. See ResizeBilinear for
+// details
+func ResizeNearest[T RealTypes](images *Tensor[T], height, width int, options ...ResizeOption) (*Tensor[T], error) {
+	o, err := newResizeOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return resize[T, T](images, height, width, func(scope *op.Scope, x, size tf.Output) tf.Output {
+		return op.ResizeNearestNeighbor(
+			scope,
+			x,
+			size,
+			op.ResizeNearestNeighborAlignCorners(o.alignCorners),
+			op.ResizeNearestNeighborHalfPixelCenters(o.halfPixelCenters),
+		)
+	})
+}
+
+// ResizeBicubic resizes images to height and width using bicubic
+// interpolation. See ResizeBilinear for details
+func ResizeBicubic[T RealTypes](images *Tensor[T], height, width int, options ...ResizeOption) (*Tensor[float32], error) {
+	o, err := newResizeOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return resize[T, float32](images, height, width, func(scope *op.Scope, x, size tf.Output) tf.Output {
+		return op.ResizeBicubic(
+			scope,
+			x,
+			size,
+			op.ResizeBicubicAlignCorners(o.alignCorners),
+			op.ResizeBicubicHalfPixelCenters(o.halfPixelCenters),
+		)
+	})
+}
+
+// CropAndResize crops boxes out of images of shape [batch, height, width,
+// channels] and resizes each crop to height and width. boxes has shape
+// [numBoxes, 4] with each row [y1, x1, y2, x2] in coordinates normalized
+// to [0, 1] and boxIndices has shape [numBoxes] holding index of image for
+// each box. Output has shape [numBoxes, height, width, channels]. Images
+// of data type uint32 or uint64 are not supported
+func CropAndResize[T RealTypes](
+	images *Tensor[T],
+	boxes *Tensor[float32],
+	boxIndices *Tensor[int32],
+	height, width int,
+	options ...CropAndResizeOption,
+) (*Tensor[float32], error) {
+	if images == nil || boxes == nil || boxIndices == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if err := checkResizeType[T](); err != nil {
+		return nil, err
+	}
+
+	if len(images.shape) != 4 {
+		return nil, fmt.Errorf("images need shape [batch, height, width, channels], got %v", images.shape)
+	}
+
+	if len(boxes.shape) != 2 || boxes.shape[1] != 4 {
+		return nil, fmt.Errorf("boxes need shape [numBoxes, 4], got %v", boxes.shape)
+	}
+
+	if len(boxIndices.shape) != 1 || boxIndices.shape[0] != boxes.shape[0] {
+		return nil, fmt.Errorf("box indices need shape [%d], got %v", boxes.shape[0], boxIndices.shape)
+	}
+
+	for i, index := range boxIndices.value {
+		if index < 0 || int(index) >= images.shape[0] {
+			return nil, fmt.Errorf("box index %d at %d out of range for batch of %d images", index, i, images.shape[0])
+		}
+	}
+
+	if height <= 0 || width <= 0 {
+		return nil, fmt.Errorf("height and width need to be positive, got %d and %d", height, width)
+	}
+
+	o := &cropAndResizeOptions{}
+	for _, option := range options {
+		option(o)
+	}
+
+	method := "bilinear"
+	if o.nearest {
+		method = "nearest"
+	}
+
+	x, err := images.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	b, err := boxes.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	i, err := boxIndices.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	B := placeholder(root, "boxes", b)
+	I := placeholder(root, "boxIndices", i)
+	Output := op.CropAndResize(
+		root,
+		X,
+		B,
+		I,
+		op.Const(root.SubScope("cropSize"), []int32{int32(height), int32(width)}),
+		op.CropAndResizeMethod(method),
+		op.CropAndResizeExtrapolationValue(o.extrapolation),
+	)
+
+	return runOutput[float32](root, map[tf.Output]*tf.Tensor{X: x, B: b, I: i}, Output)
+}
+
+// CentralCrop crops central region of images of shape [height, width,
+// channels] or [batch, height, width, channels] retaining fraction of
+// height and width, which needs to be in (0, 1]
+func CentralCrop[T PrimitiveTypes](images *Tensor[T], fraction float64) (*Tensor[T], error) {
+	if fraction <= 0 || fraction > 1 {
+		return nil, fmt.Errorf("fraction needs to be in (0, 1], got %v", fraction)
+	}
+
+	rank, err := imageRank(images)
+	if err != nil {
+		return nil, err
+	}
+
+	// offsets are rounded down and sizes are such that crop
+	// stays centered
+	begin := make([]int64, rank)
+	size := make([]int64, rank)
+	for i, dim := range images.shape {
+		size[i] = int64(dim)
+		if i == rank-3 || i == rank-2 {
+			begin[i] = int64((float64(dim) - float64(dim)*fraction) / 2)
+			size[i] = int64(dim) - 2*begin[i]
+		}
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			return op.Slice(
+				scope,
+				outputs[0],
+				op.Const(scope.SubScope("begin"), begin),
+				op.Const(scope.SubScope("size"), size),
+			), nil
+		},
+		images,
+	)
+}
+
+// FlipLeftRight flips images of shape [height, width, channels] or
+// [batch, height, width, channels] along width
+func FlipLeftRight[T PrimitiveTypes](images *Tensor[T]) (*Tensor[T], error) {
+	return flip(images, 2)
+}
+
+// FlipUpDown flips images of shape [height, width, channels] or
+// [batch, height, width, channels] along height
+func FlipUpDown[T PrimitiveTypes](images *Tensor[T]) (*Tensor[T], error) {
+	return flip(images, 3)
+}
+
+// PerImageStandardization scales each image of shape [height, width,
+// channels] or [batch, height, width, channels] to zero mean and unit
+// variance. Standard deviation is bounded below by 1/sqrt(n), where n is
+// the number of elements of an image, to avoid division by zero for
+// uniform images
+func PerImageStandardization[T RealTypes](images *Tensor[T]) (*Tensor[float32], error) {
+	rank, err := imageRank(images)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := numElements(images.shape[rank-3:])
+	if err != nil {
+		return nil, err
+	}
+
+	axes := []int32{int32(rank - 3), int32(rank - 2), int32(rank - 1)}
+
+	x, err := images.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	images32 := op.Cast(root, X, tf.Float)
+	mean := op.Mean(root, images32, op.Const(root.SubScope("axes"), axes), op.MeanKeepDims(true))
+	centered := op.Sub(root, images32, mean)
+	variance := op.Mean(
+		root.SubScope("variance"),
+		op.Square(root, centered),
+		op.Const(root.SubScope("axes"), axes),
+		op.MeanKeepDims(true),
+	)
+	std := op.Maximum(
+		root,
+		op.Sqrt(root, variance),
+		op.Const(root.SubScope("minStd"), float32(1/math.Sqrt(float64(n)))),
+	)
+
+	return runOutput[float32](root, map[tf.Output]*tf.Tensor{X: x}, op.Div(root, centered, std))
+}
+
+// imageRank checks that images have shape [height, width, channels] or
+// [batch, height, width, channels] and returns its rank
+func imageRank[T PrimitiveTypes](images *Tensor[T]) (int, error) {
+	if images == nil {
+		return 0, fmt.Errorf("images can't be nil")
+	}
+
+	rank := len(images.shape)
+	if rank != 3 && rank != 4 {
+		return 0, fmt.Errorf(
+			"images need shape [height, width, channels] or [batch, height, width, channels], got %v",
+			images.shape,
+		)
+	}
+
+	return rank, nil
+}
+
+// checkImage checks that image has shape [height, width, channels] with
+// one of allowed number of channels
+func checkImage(image *Tensor[uint8], channels ...int) error {
+	if image == nil {
+		return fmt.Errorf("image can't be nil")
+	}
+
+	if len(image.shape) != 3 {
+		return fmt.Errorf("image needs shape [height, width, channels], got %v", image.shape)
+	}
+
+	for _, c := range channels {
+		if image.shape[2] == c {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("image needs %v channels, got %d", channels, image.shape[2])
+}
+
+// encodeImage encodes image via encoder f
+func encodeImage(image *Tensor[uint8], f func(scope *op.Scope, x tf.Output) tf.Output) ([]byte, error) {
+	x, err := image.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, f(root, X))
+	if err != nil {
+		return nil, err
+	}
+
+	contents, ok := out[0].Value().(string)
+	if !ok {
+		return nil, fmt.Errorf("expected encoded image of data type string, got %T", out[0].Value())
+	}
+
+	return []byte(contents), nil
+}
+
+// checkResizeType rejects data types that tensorflow resize kernels,
+// which are registered for real number types, do not support
+func checkResizeType[T RealTypes]() error {
+	switch any(*new(T)).(type) {
+	case uint32, uint64:
+		return fmt.Errorf("resizing images of data type %T is not supported", *new(T))
+	}
+
+	return nil
+}
+
+// resize resizes images via f, adding a batch axis for a single image
+func resize[T RealTypes, O PrimitiveTypes](
+	images *Tensor[T],
+	height, width int,
+	f func(scope *op.Scope, x, size tf.Output) tf.Output,
+) (*Tensor[O], error) {
+	rank, err := imageRank(images)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkResizeType[T](); err != nil {
+		return nil, err
+	}
+
+	if height <= 0 || width <= 0 {
+		return nil, fmt.Errorf("height and width need to be positive, got %d and %d", height, width)
+	}
+
+	x, err := images.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	input := X
+	if rank == 3 {
+		input = op.ExpandDims(root, X, op.Const(root.SubScope("axis"), int32(0)))
+	}
+
+	Output := f(root, input, op.Const(root.SubScope("size"), []int32{int32(height), int32(width)}))
+	if rank == 3 {
+		Output = op.Squeeze(root, Output, op.SqueezeAxis([]int64{0}))
+	}
+
+	return runOutput[O](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// flip reverses images along axis counted from the end of shape
+func flip[T PrimitiveTypes](images *Tensor[T], axis int) (*Tensor[T], error) {
+	rank, err := imageRank(images)
+	if err != nil {
+		return nil, err
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			return op.ReverseV2(scope, outputs[0], op.Const(scope.SubScope("axis"), []int32{int32(rank - axis)})), nil
+		},
+		images,
+	)
+}
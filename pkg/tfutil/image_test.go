@@ -0,0 +1,242 @@
+package tfutil
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// newTestImage creates an RGB image with distinct values per pixel
+// along with its expected tensor values
+func newTestImage(height, width int) (*image.RGBA, []uint8) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	values := make([]uint8, 0, height*width*3)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: uint8(10 * y), G: uint8(10 * x), B: uint8(10*y + x), A: 255}
+			img.Set(x, y, c)
+			values = append(values, c.R, c.G, c.B)
+		}
+	}
+
+	return img, values
+}
+
+func TestDecodeEncodeImage(t *testing.T) {
+	img, values := newTestImage(3, 4)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeImage(buf.Bytes(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(decoded.value, values) || !equal(decoded.shape, []int{3, 4, 3}) {
+		t.Fatal("decoded png does not match expected value")
+	}
+
+	encoded, err := EncodePng(decoded, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roundTrip, err := png.Decode(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r, g, b, _ := roundTrip.At(2, 1).RGBA(); r>>8 != 10 || g>>8 != 20 || b>>8 != 12 {
+		t.Fatal("encoded png does not match expected value")
+	}
+
+	jpeg, err := EncodeJpeg(decoded, 95)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded, err = DecodeImage(jpeg, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(decoded.shape, []int{3, 4, 3}) {
+		t.Fatalf("expected decoded jpeg of shape [3 4 3], got %v", decoded.shape)
+	}
+
+	// black and white are exact in gif palette
+	paletted := image.NewPaletted(image.Rect(0, 0, 2, 1), color.Palette{color.Black, color.White})
+	paletted.SetColorIndex(1, 0, 1)
+
+	buf.Reset()
+	if err := gif.Encode(&buf, paletted, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded, err = DecodeImage(buf.Bytes(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(decoded.value, []uint8{0, 0, 0, 255, 255, 255}) || !equal(decoded.shape, []int{1, 2, 3}) {
+		t.Fatal("decoded gif does not match expected value")
+	}
+
+	if _, err := EncodeJpeg(decoded, 101); err == nil {
+		t.Fatal("expected encode jpeg to fail for quality above 100")
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	images, err := NewTensor([]uint8{1, 2, 3, 4}, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nearest, err := ResizeNearest(images, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(nearest.value, []uint8{1, 1, 2, 2, 1, 1, 2, 2, 3, 3, 4, 4, 3, 3, 4, 4}) ||
+		!equal(nearest.shape, []int{4, 4, 1}) {
+		t.Fatal("nearest resize does not match expected value")
+	}
+
+	// corners of output align with corners of input
+	bilinear, err := ResizeBilinear(images, 3, 3, ResizeAlignCorners(true), ResizeHalfPixelCenters(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(bilinear.value, []float32{1, 1.5, 2, 2, 2.5, 3, 3, 3.5, 4}, 1e-6) {
+		t.Fatalf("bilinear resize does not match expected value, got %v", bilinear.value)
+	}
+
+	batch, err := NewTensor([]float32{5, 5, 5, 5, 7, 7, 7, 7}, 2, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// resizing constant images retains their values
+	bicubic, err := ResizeBicubic(batch, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(bicubic.shape, []int{2, 3, 5, 1}) ||
+		math.Abs(float64(bicubic.value[0])-5) > 1e-5 ||
+		math.Abs(float64(bicubic.value[len(bicubic.value)-1])-7) > 1e-5 {
+		t.Fatal("bicubic resize does not match expected value")
+	}
+
+	if _, err := ResizeBilinear(images, 3, 3, ResizeAlignCorners(true)); err == nil {
+		t.Fatal("expected resize to fail for both align corners and half pixel centers")
+	}
+
+	wide, err := NewTensor([]uint32{1, 2, 3, 4}, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ResizeNearest(wide, 4, 4); err == nil {
+		t.Fatal("expected nearest resize to fail for uint32 images")
+	}
+}
+
+func TestCropImage(t *testing.T) {
+	images, err := NewTensorFromFunc(func(i int) float32 { return float32(i) }, 1, 4, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boxes, err := NewTensor([]float32{0, 0, 1, 1, 0, 0, 1.0 / 3, 1.0 / 3}, 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boxIndices, err := NewTensor([]int32{0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crops, err := CropAndResize(images, boxes, boxIndices, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// first box samples corners of image and second box samples
+	// top left 2x2 pixels
+	if !allClose(crops.value, []float32{0, 3, 12, 15, 0, 1, 4, 5}, 1e-5) || !equal(crops.shape, []int{2, 2, 2, 1}) {
+		t.Fatalf("crop and resize does not match expected value, got %v", crops.value)
+	}
+
+	central, err := CentralCrop(images, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(central.value, []float32{5, 6, 9, 10}) || !equal(central.shape, []int{1, 2, 2, 1}) {
+		t.Fatal("central crop does not match expected value")
+	}
+
+	if _, err := CentralCrop(images, 0); err == nil {
+		t.Fatal("expected central crop to fail for zero fraction")
+	}
+
+	wide, err := NewTensor(make([]uint64, 16), 1, 4, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CropAndResize(wide, boxes, boxIndices, 2, 2); err == nil {
+		t.Fatal("expected crop and resize to fail for uint64 images")
+	}
+}
+
+func TestFlipImage(t *testing.T) {
+	images, err := NewTensor([]uint8{1, 2, 3, 4, 5, 6}, 2, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leftRight, err := FlipLeftRight(images)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(leftRight.value, []uint8{3, 2, 1, 6, 5, 4}) {
+		t.Fatal("left right flip does not match expected value")
+	}
+
+	upDown, err := FlipUpDown(images)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(upDown.value, []uint8{4, 5, 6, 1, 2, 3}) {
+		t.Fatal("up down flip does not match expected value")
+	}
+}
+
+func TestPerImageStandardization(t *testing.T) {
+	images, err := NewTensor([]uint8{1, 3, 1, 3, 9, 9, 9, 9}, 2, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	standardized, err := PerImageStandardization(images)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// second image is uniform and centers to zeros
+	if !allClose(standardized.value, []float32{-1, 1, -1, 1, 0, 0, 0, 0}, 1e-6) {
+		t.Fatalf("per image standardization does not match expected value, got %v", standardized.value)
+	}
+}
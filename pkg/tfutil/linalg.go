@@ -0,0 +1,435 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// Solve solves systems of linear equations a * x = b for x, where a has
+// shape [..., M, M] and b has shape [..., M, K]. Batch dimensions of
+// a and b, if any, need to be identical
+func Solve[T InexactTypes](a, b *Tensor[T]) (*Tensor[T], error) {
+	if err := checkSystem(a, b); err != nil {
+		return nil, err
+	}
+
+	if err := checkBatch(a, b); err != nil {
+		return nil, err
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			return op.MatrixSolve(scope, outputs[0], outputs[1]), nil
+		},
+		a, b,
+	)
+}
+
+// TriangularSolve solves systems of linear equations a * x = b for x,
+// where a of shape [..., M, M] is a lower triangular matrix if lower is
+// true and upper triangular otherwise. Elements of a in the other
+// triangle are ignored. b has shape [..., M, K] and batch dimensions of
+// a and b are broadcast
+func TriangularSolve[T InexactTypes](a, b *Tensor[T], lower bool) (*Tensor[T], error) {
+	if err := checkSystem(a, b); err != nil {
+		return nil, err
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			return op.MatrixTriangularSolve(
+				scope,
+				outputs[0],
+				outputs[1],
+				op.MatrixTriangularSolveLower(lower),
+			), nil
+		},
+		a, b,
+	)
+}
+
+// Lstsq solves linear least squares problems, i.e., finds x minimizing
+// ||a * x - b||^2 + l2Regularizer * ||x||^2, where a has shape [..., M, N]
+// and b has shape [..., M, K]. Batch dimensions of a and b, if any, need
+// to be identical. Output has shape [..., N, K]
+func Lstsq[T InexactTypes](a, b *Tensor[T], l2Regularizer float64) (*Tensor[T], error) {
+	if err := checkMatrix(a); err != nil {
+		return nil, fmt.Errorf("invalid matrix a: %w", err)
+	}
+
+	if err := checkMatrix(b); err != nil {
+		return nil, fmt.Errorf("invalid matrix b: %w", err)
+	}
+
+	if a.shape[len(a.shape)-2] != b.shape[len(b.shape)-2] {
+		return nil, fmt.Errorf(
+			"number of rows of a and b need to match, got %d and %d",
+			a.shape[len(a.shape)-2], b.shape[len(b.shape)-2],
+		)
+	}
+
+	if err := checkBatch(a, b); err != nil {
+		return nil, err
+	}
+
+	if l2Regularizer < 0 {
+		return nil, fmt.Errorf("l2Regularizer can't be negative, got %v", l2Regularizer)
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			return op.MatrixSolveLs(
+				scope,
+				outputs[0],
+				outputs[1],
+				op.Const(scope.SubScope("l2Regularizer"), l2Regularizer),
+			), nil
+		},
+		a, b,
+	)
+}
+
+// Det computes determinants of square matrices of input of shape
+// [..., M, M]. Output has batch shape [...], which, for an input
+// that is a single matrix, is represented as a vector of length 1
+func Det[T InexactTypes](input *Tensor[T]) (*Tensor[T], error) {
+	if err := checkSquare(input); err != nil {
+		return nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	Output := op.MatrixDeterminant(root, X)
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// LogDet computes sign and log of absolute value of determinants of
+// square matrices of input of shape [..., M, M], which is numerically
+// more stable than Det for large matrices. Outputs have batch shape
+// [...], which, for an input that is a single matrix, is represented
+// as a vector of length 1
+func LogDet[T InexactTypes](input *Tensor[T]) (sign, logAbsDet *Tensor[T], err error) {
+	if err := checkSquare(input); err != nil {
+		return nil, nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	Sign, LogAbsDet := op.LogMatrixDeterminant(root, X)
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, Sign, LogAbsDet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if sign, err = fromTfTensor[T](out[0]); err != nil {
+		return nil, nil, err
+	}
+
+	if logAbsDet, err = fromTfTensor[T](out[1]); err != nil {
+		return nil, nil, err
+	}
+
+	return sign, logAbsDet, nil
+}
+
+// Cholesky computes Cholesky decomposition of symmetric (or hermitian)
+// positive definite matrices of input of shape [..., M, M]. Output is a
+// lower triangular matrix l such that input = l * adjoint(l)
+func Cholesky[T InexactTypes](input *Tensor[T]) (*Tensor[T], error) {
+	if err := checkSquare(input); err != nil {
+		return nil, err
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			return op.Cholesky(scope, outputs[0]), nil
+		},
+		input,
+	)
+}
+
+// QR computes QR decomposition of matrices of input of shape [..., M, N]
+// such that input = q * r, where q is orthonormal (or unitary) and r is
+// upper triangular. If fullMatrices is true, q has shape [..., M, M] and
+// r has shape [..., M, N], otherwise with P = min(M, N), q has shape
+// [..., M, P] and r has shape [..., P, N]
+func QR[T InexactTypes](input *Tensor[T], fullMatrices bool) (q, r *Tensor[T], err error) {
+	if err := checkMatrix(input); err != nil {
+		return nil, nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	Q, R := op.Qr(root, X, op.QrFullMatrices(fullMatrices))
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, Q, R)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if q, err = fromTfTensor[T](out[0]); err != nil {
+		return nil, nil, err
+	}
+
+	if r, err = fromTfTensor[T](out[1]); err != nil {
+		return nil, nil, err
+	}
+
+	return q, r, nil
+}
+
+// SVD computes singular value decomposition of matrices of input of shape
+// [..., M, N] such that input = u * diag(s) * adjoint(v). With P = min(M, N),
+// s has shape [..., P] holding singular values in descending order. If
+// fullMatrices is true, u has shape [..., M, M] and v has shape [..., N, N],
+// otherwise u has shape [..., M, P] and v has shape [..., N, P].
+// Singular values of complex input are returned with zero imaginary part
+func SVD[T InexactTypes](input *Tensor[T], fullMatrices bool) (s, u, v *Tensor[T], err error) {
+	if err := checkMatrix(input); err != nil {
+		return nil, nil, nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	S, U, V := op.Svd(root, X, op.SvdComputeUv(true), op.SvdFullMatrices(fullMatrices))
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, S, U, V)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if s, err = fromTfTensor[T](out[0]); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if u, err = fromTfTensor[T](out[1]); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if v, err = fromTfTensor[T](out[2]); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return s, u, v, nil
+}
+
+// SelfAdjointEig computes eigen decomposition of symmetric (or hermitian)
+// matrices of input of shape [..., M, M]. Eigenvalues e of shape [..., M]
+// are in ascending order and columns of v of shape [..., M, M] are the
+// corresponding eigenvectors. Eigenvalues of complex input are returned
+// with zero imaginary part
+func SelfAdjointEig[T InexactTypes](input *Tensor[T]) (e, v *Tensor[T], err error) {
+	if err := checkSquare(input); err != nil {
+		return nil, nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	E, V := op.SelfAdjointEigV2(root, X, op.SelfAdjointEigV2ComputeV(true))
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, E, V)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e, err = fromTfTensor[T](out[0]); err != nil {
+		return nil, nil, err
+	}
+
+	if v, err = fromTfTensor[T](out[1]); err != nil {
+		return nil, nil, err
+	}
+
+	return e, v, nil
+}
+
+// Eig computes eigen decomposition of general square matrices of input
+// of shape [..., M, M]. Eigenvalues e of shape [..., M] and eigenvectors
+// in columns of v of shape [..., M, M] are complex valued in general,
+// so output data type C needs to be provided, i.e., complex64 for
+// float32 or complex64 input and complex128 otherwise:
+//
+//	e, v, err := Eig[complex128](x)
+func Eig[C ComplexTypes, T InexactTypes](input *Tensor[T]) (e, v *Tensor[C], err error) {
+	if err := checkSquare(input); err != nil {
+		return nil, nil, err
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	dataType, err := dataTypeOf[C]()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	E, V := op.Eig(root, X, dataType, op.EigComputeV(true))
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, E, V)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e, err = fromTfTensor[C](out[0]); err != nil {
+		return nil, nil, err
+	}
+
+	if v, err = fromTfTensor[C](out[1]); err != nil {
+		return nil, nil, err
+	}
+
+	return e, v, nil
+}
+
+// Pinv computes Moore-Penrose pseudo-inverse of matrices of input of
+// shape [..., M, N] via singular value decomposition. Output has shape
+// [..., N, M]. Singular values less than or equal to rcond times the
+// largest singular value are treated as zero. If rcond is not positive,
+// it defaults to 10 * max(M, N) * machine epsilon of the data type
+func Pinv[T InexactTypes](input *Tensor[T], rcond float64) (*Tensor[T], error) {
+	if err := checkMatrix(input); err != nil {
+		return nil, err
+	}
+
+	if rcond <= 0 {
+		eps := 2.220446049250313e-16
+		switch any(*new(T)).(type) {
+		case float32, complex64:
+			eps = 1.1920929e-07
+		}
+		rcond = 10 * float64(max(input.shape[len(input.shape)-1], input.shape[len(input.shape)-2])) * eps
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	S, U, V := op.Svd(root, X, op.SvdComputeUv(true), op.SvdFullMatrices(false))
+
+	// singular values are compared in corresponding real data type
+	// since complex values are not ordered
+	switch any(*new(T)).(type) {
+	case complex64:
+		S = op.Real(root.SubScope("real"), S, op.RealTout(tf.Float))
+	case complex128:
+		S = op.Real(root.SubScope("real"), S, op.RealTout(tf.Double))
+	}
+
+	cutoff := op.Mul(
+		root.SubScope("cutoff"),
+		op.Max(root, S, op.Const(root.SubScope("axis"), int64(-1)), op.MaxKeepDims(true)),
+		op.Cast(root.SubScope("rcond"), op.Const(root.SubScope("rcond"), rcond), S.DataType()),
+	)
+
+	// reciprocal of singular values above cutoff, zero otherwise
+	SInv := op.Select(
+		root,
+		op.Greater(root, S, cutoff),
+		op.Reciprocal(root, S),
+		op.ZerosLike(root, S),
+	)
+	SInv = op.Cast(root.SubScope("sInv"), SInv, x.DataType())
+
+	// pinv = v * diag(sInv) * adjoint(u)
+	Output := op.BatchMatMulV2(
+		root,
+		op.Mul(root.SubScope("scale"), V, op.ExpandDims(root, SInv, op.Const(root.SubScope("dim"), int64(-2)))),
+		U,
+		op.BatchMatMulV2AdjY(true),
+	)
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// checkMatrix checks if input has rank at least 2, i.e., shape [..., M, N]
+func checkMatrix[T PrimitiveTypes](input *Tensor[T]) error {
+	if input == nil {
+		return fmt.Errorf("input can't be nil")
+	}
+
+	if len(input.shape) < 2 {
+		return fmt.Errorf("input needs to have shape [..., M, N], got %v", input.shape)
+	}
+
+	return nil
+}
+
+// checkSquare checks if input has shape [..., M, M]
+func checkSquare[T PrimitiveTypes](input *Tensor[T]) error {
+	if err := checkMatrix(input); err != nil {
+		return err
+	}
+
+	if input.shape[len(input.shape)-1] != input.shape[len(input.shape)-2] {
+		return fmt.Errorf("input needs to have shape [..., M, M], got %v", input.shape)
+	}
+
+	return nil
+}
+
+// checkSystem checks shapes of linear system a * x = b, where a has shape
+// [..., M, M] and b has shape [..., M, K]
+func checkSystem[T PrimitiveTypes](a, b *Tensor[T]) error {
+	if err := checkSquare(a); err != nil {
+		return fmt.Errorf("invalid matrix a: %w", err)
+	}
+
+	if err := checkMatrix(b); err != nil {
+		return fmt.Errorf("invalid matrix b: %w", err)
+	}
+
+	if a.shape[len(a.shape)-1] != b.shape[len(b.shape)-2] {
+		return fmt.Errorf(
+			"number of rows of b needs to be %d to match a, got %d",
+			a.shape[len(a.shape)-1], b.shape[len(b.shape)-2],
+		)
+	}
+
+	return nil
+}
+
+// checkBatch checks that batch dimensions of matrices a and b, i.e., all
+// but the two innermost dimensions, are identical
+func checkBatch[T PrimitiveTypes](a, b *Tensor[T]) error {
+	if !equal(a.shape[:len(a.shape)-2], b.shape[:len(b.shape)-2]) {
+		return fmt.Errorf("batch dimensions of a and b need to match, got shapes %v and %v", a.shape, b.shape)
+	}
+
+	return nil
+}
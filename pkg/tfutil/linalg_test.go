@@ -0,0 +1,266 @@
+package tfutil
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// allClose checks element wise closeness of two slices
+func allClose[T InexactTypes](x, y []T, tol float64) bool {
+	if len(x) != len(y) {
+		return false
+	}
+
+	for i := range x {
+		if cmplx.Abs(toComplex128(x[i])-toComplex128(y[i])) > tol {
+			return false
+		}
+	}
+
+	return true
+}
+
+func toComplex128[T InexactTypes](v T) complex128 {
+	switch v := any(v).(type) {
+	case float32:
+		return complex(float64(v), 0)
+	case float64:
+		return complex(v, 0)
+	case complex64:
+		return complex128(v)
+	case complex128:
+		return v
+	default:
+		return cmplx.NaN()
+	}
+}
+
+func TestSolve(t *testing.T) {
+	a, err := NewTensor([]float64{3, 1, 1, 2}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewTensor([]float64{9, 8}, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := Solve(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(x.value, []float64{2, 3}, 1e-12) || !equal(x.shape, []int{2, 1}) {
+		t.Fatal("output does not match expected value")
+	}
+
+	c, err := NewTensor([]float64{1, 2, 3}, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Solve(a, c); err == nil {
+		t.Fatal("expected solve to fail for mismatched shapes")
+	}
+	batch, err := NewTensor([]float64{9, 8, 9, 8}, 2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Solve(a, batch); err == nil {
+		t.Fatal("expected solve to fail for mismatched batch dimensions")
+	}
+}
+
+func TestTriangularSolve(t *testing.T) {
+	a, err := NewTensor([]float64{2, 100, 1, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewTensor([]float64{4, 10}, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// upper triangle of a is ignored
+	x, err := TriangularSolve(a, b, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(x.value, []float64{2, 2}, 1e-12) {
+		t.Fatal("output does not match expected value")
+	}
+}
+
+func TestDetLogDet(t *testing.T) {
+	a, err := NewTensor([]float64{1, 2, 3, 4, 2, 0, 0, 3}, 2, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	det, err := Det(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(det.value, []float64{-2, 6}, 1e-12) || !equal(det.shape, []int{2}) {
+		t.Fatal("det does not match expected value")
+	}
+
+	sign, logAbsDet, err := LogDet(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(sign.value, []float64{-1, 1}, 1e-12) ||
+		!allClose(logAbsDet.value, []float64{math.Log(2), math.Log(6)}, 1e-12) {
+		t.Fatal("log det does not match expected value")
+	}
+
+	b, err := NewTensor([]float32{2, 0, 0, 3}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	det32, err := Det(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(det32.value, []float32{6}, 1e-6) || !equal(det32.shape, []int{1}) {
+		t.Fatal("det of single matrix does not match expected value")
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	a, err := NewTensor([]float64{4, 2, 2, 3}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Cholesky(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(l.value, []float64{2, 0, 1, math.Sqrt(2)}, 1e-12) {
+		t.Fatal("output does not match expected value")
+	}
+}
+
+func TestQR(t *testing.T) {
+	a, err := NewTensor([]float64{1, 2, 3, 4, 5, 6}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, r, err := QR(a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(q.shape, []int{3, 2}) || !equal(r.shape, []int{2, 2}) {
+		t.Fatal("factor shapes do not match expected shapes")
+	}
+
+	qr, err := MatrixMultiply(q, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(qr.value, a.value, 1e-12) {
+		t.Fatal("q * r does not reconstruct input")
+	}
+}
+
+func TestSVD(t *testing.T) {
+	a, err := NewTensor([]float64{3, 0, 0, 0, -2, 0}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, u, v, err := SVD(a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(s.value, []float64{3, 2}, 1e-12) {
+		t.Fatal("singular values do not match expected values")
+	}
+
+	if !equal(u.shape, []int{2, 2}) || !equal(v.shape, []int{3, 2}) {
+		t.Fatal("factor shapes do not match expected shapes")
+	}
+}
+
+func TestSelfAdjointEigAndEig(t *testing.T) {
+	a, err := NewTensor([]float64{2, 1, 1, 2}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, v, err := SelfAdjointEig(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(e.value, []float64{1, 3}, 1e-12) || !equal(v.shape, []int{2, 2}) {
+		t.Fatal("eigenvalues do not match expected values")
+	}
+
+	// rotation by 90 degrees has eigenvalues +i and -i
+	b, err := NewTensor([]float64{0, -1, 1, 0}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ec, _, err := Eig[complex128](b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !(allClose(ec.value, []complex128{1i, -1i}, 1e-12) || allClose(ec.value, []complex128{-1i, 1i}, 1e-12)) {
+		t.Fatal("eigenvalues do not match expected values")
+	}
+}
+
+func TestPinvLstsq(t *testing.T) {
+	// rank deficient matrix
+	a, err := NewTensor([]float64{1, 2, 2, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Pinv(a, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(p.value, []float64{0.04, 0.08, 0.08, 0.16}, 1e-12) {
+		t.Fatal("pseudo inverse does not match expected value")
+	}
+
+	x, err := NewTensor([]float64{1, 1, 1, 2, 1, 3}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float64{1, 3, 5}, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fit of y = -1 + 2 * t
+	w, err := Lstsq(x, y, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(w.value, []float64{-1, 2}, 1e-9) {
+		t.Fatal("least squares solution does not match expected value")
+	}
+}
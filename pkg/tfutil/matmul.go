@@ -0,0 +1,182 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// MatMulOption configures matrix multiplication performed by
+// MatrixMultiply and MatrixMultiplyOp
+type MatMulOption func(*matMulOptions)
+
+type matMulOptions struct {
+	transposeA bool
+	transposeB bool
+	adjointA   bool
+	adjointB   bool
+}
+
+// MatMulTransposeA sets transposition of innermost matrices of
+// the first input prior to multiplication
+func MatMulTransposeA(value bool) MatMulOption {
+	return func(o *matMulOptions) {
+		o.transposeA = value
+	}
+}
+
+// MatMulTransposeB sets transposition of innermost matrices of
+// the second input prior to multiplication
+func MatMulTransposeB(value bool) MatMulOption {
+	return func(o *matMulOptions) {
+		o.transposeB = value
+	}
+}
+
+// MatMulAdjointA sets adjoint, i.e., conjugate transpose, of innermost
+// matrices of the first input prior to multiplication. It is same as
+// MatMulTransposeA for data types that are not complex
+func MatMulAdjointA(value bool) MatMulOption {
+	return func(o *matMulOptions) {
+		o.adjointA = value
+	}
+}
+
+// MatMulAdjointB sets adjoint, i.e., conjugate transpose, of innermost
+// matrices of the second input prior to multiplication. It is same as
+// MatMulTransposeB for data types that are not complex
+func MatMulAdjointB(value bool) MatMulOption {
+	return func(o *matMulOptions) {
+		o.adjointB = value
+	}
+}
+
+func newMatMulOptions(options []MatMulOption) *matMulOptions {
+	o := &matMulOptions{}
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// MatrixMultiplyOp returns an operator that multiplies innermost matrices
+// of two inputs of shapes [..., M, K] and [..., K, N] resulting in shape
+// [..., M, N]. Batch dimensions, if any, are broadcast against each other.
+// Rank 2 inputs are multiplied via MatMul op and BatchMatMulV2 op is
+// used otherwise
+func MatrixMultiplyOp(options ...MatMulOption) Operator {
+	return func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+		if len(outputs) != 2 {
+			return tf.Output{}, fmt.Errorf("operator MatrixMultiply needs len outputs = 2, got %d", len(outputs))
+		}
+
+		o := newMatMulOptions(options)
+		if (o.transposeA && o.adjointA) || (o.transposeB && o.adjointB) {
+			return tf.Output{}, fmt.Errorf("transpose and adjoint can't be set together for the same input")
+		}
+
+		x, y := outputs[0], outputs[1]
+		xShape, err := x.Shape().ToSlice()
+		if err != nil {
+			return tf.Output{}, fmt.Errorf("operator MatrixMultiply needs known rank of first input: %w", err)
+		}
+
+		yShape, err := y.Shape().ToSlice()
+		if err != nil {
+			return tf.Output{}, fmt.Errorf("operator MatrixMultiply needs known rank of second input: %w", err)
+		}
+
+		if _, err := matMulShape(xShape, yShape, o); err != nil {
+			return tf.Output{}, err
+		}
+
+		if len(xShape) == 2 && len(yShape) == 2 && !o.adjointA && !o.adjointB {
+			return op.MatMul(
+				scope,
+				x,
+				y,
+				op.MatMulTransposeA(o.transposeA),
+				op.MatMulTransposeB(o.transposeB),
+			), nil
+		}
+
+		// BatchMatMulV2 only has adjoint flags, which are same as
+		// transpose for data types that are not complex
+		adjX, adjY := o.adjointA, o.adjointB
+		if o.transposeA {
+			if isComplex(x.DataType()) {
+				x = transposeInner(scope.SubScope("transposeA"), x, len(xShape))
+			} else {
+				adjX = true
+			}
+		}
+
+		if o.transposeB {
+			if isComplex(y.DataType()) {
+				y = transposeInner(scope.SubScope("transposeB"), y, len(yShape))
+			} else {
+				adjY = true
+			}
+		}
+
+		return op.BatchMatMulV2(
+			scope,
+			x,
+			y,
+			op.BatchMatMulV2AdjX(adjX),
+			op.BatchMatMulV2AdjY(adjY),
+		), nil
+	}
+}
+
+// matMulShape validates shapes of matrix multiplication inputs and returns
+// shape of the output
+func matMulShape(xShape, yShape []int64, o *matMulOptions) ([]int64, error) {
+	if len(xShape) < 2 || len(yShape) < 2 {
+		return nil, fmt.Errorf(
+			"matrix multiplication needs inputs of rank at least 2, got shapes %v and %v", xShape, yShape,
+		)
+	}
+
+	m, k1 := xShape[len(xShape)-2], xShape[len(xShape)-1]
+	if o.transposeA || o.adjointA {
+		m, k1 = k1, m
+	}
+
+	k2, n := yShape[len(yShape)-2], yShape[len(yShape)-1]
+	if o.transposeB || o.adjointB {
+		k2, n = n, k2
+	}
+
+	if k1 >= 0 && k2 >= 0 && k1 != k2 {
+		return nil, fmt.Errorf(
+			"inner dimensions do not match: first input of shape %v contributes %d (transposed: %v) and second input of shape %v contributes %d (transposed: %v)",
+			xShape, k1, o.transposeA || o.adjointA, yShape, k2, o.transposeB || o.adjointB,
+		)
+	}
+
+	batch, err := broadcastShapes(xShape[:len(xShape)-2], yShape[:len(yShape)-2])
+	if err != nil {
+		return nil, fmt.Errorf("batch dimensions do not match: %w", err)
+	}
+
+	return append(batch, m, n), nil
+}
+
+// transposeInner swaps last two dimensions of x of given rank
+func transposeInner(scope *op.Scope, x tf.Output, rank int) tf.Output {
+	perm := make([]int64, rank)
+	for i := range perm {
+		perm[i] = int64(i)
+	}
+	perm[rank-1], perm[rank-2] = perm[rank-2], perm[rank-1]
+
+	return op.Transpose(scope, x, op.Const(scope.SubScope("perm"), perm))
+}
+
+// isComplex checks if data type is complex
+func isComplex(dataType tf.DataType) bool {
+	return dataType == tf.Complex64 || dataType == tf.Complex128
+}
@@ -0,0 +1,129 @@
+package tfutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatrixMultiplyBatched(t *testing.T) {
+	// batch of two 2x2 matrices times a single 2x2 matrix,
+	// which is broadcast over the batch
+	x, err := NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 2, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float64{1, 0, 0, 2}, 1, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := MatrixMultiply(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(z.value, []float64{1, 4, 3, 8, 5, 12, 7, 16}) || !equal(z.shape, []int{2, 2, 2}) {
+		t.Fatal("output does not match expected value")
+	}
+}
+
+func TestMatrixMultiplyTranspose(t *testing.T) {
+	x, err := NewTensor([]float32{1, 2, 3, 4, 5, 6}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float32{1, 1, 1}, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := MatrixMultiply(x, y, MatMulTransposeA(true), MatMulTransposeB(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(z.value, []float32{9, 12}) || !equal(z.shape, []int{2, 1}) {
+		t.Fatal("output does not match expected value")
+	}
+}
+
+func TestMatrixMultiplyAdjoint(t *testing.T) {
+	x, err := NewTensor([]complex128{1i, 2, 3, 4i}, 1, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]complex128{1, 0, 0, 1}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adj, err := MatrixMultiply(x, y, MatMulAdjointA(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(adj.value, []complex128{-1i, 3, 2, -4i}) || !equal(adj.shape, []int{1, 2, 2}) {
+		t.Fatal("adjoint output does not match expected value")
+	}
+
+	tr, err := MatrixMultiply(x, y, MatMulTransposeA(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(tr.value, []complex128{1i, 3, 2, 4i}) || !equal(tr.shape, []int{1, 2, 2}) {
+		t.Fatal("transpose output does not match expected value")
+	}
+}
+
+func TestMatrixMultiplyShapeMismatch(t *testing.T) {
+	x, err := NewTensor([]int32{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]int32{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MatrixMultiply(x, y); err == nil || !strings.Contains(err.Error(), "inner dimensions") {
+		t.Fatal("expected matrix multiplication to fail for mismatched inner dimensions, got", err)
+	}
+
+	a, err := NewTensor([]int32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, 3, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewTensor([]int32{1, 2, 3, 4, 5, 6, 7, 8}, 2, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MatrixMultiply(a, b); err == nil || !strings.Contains(err.Error(), "batch dimensions") {
+		t.Fatal("expected matrix multiplication to fail for mismatched batch dimensions, got", err)
+	}
+
+	if _, err := MatrixMultiply(x, y, MatMulTransposeA(true), MatMulAdjointA(true)); err == nil {
+		t.Fatal("expected matrix multiplication to fail for transpose and adjoint on same input")
+	}
+}
+
+func TestBroadcastShapes(t *testing.T) {
+	shape, err := broadcastShapes([]int64{5, 1, 3}, []int64{4, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(shape, []int64{5, 4, 3}) {
+		t.Fatal("broadcast shape does not match expected value")
+	}
+
+	if _, err := broadcastShapes([]int64{2, 3}, []int64{4, 3}); err == nil {
+		t.Fatal("expected broadcast to fail for incompatible shapes")
+	}
+}
@@ -0,0 +1,335 @@
+package tfutil
+
+import (
+	"fmt"
+	"math"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+type normKind int
+
+const (
+	normDefault normKind = iota
+	normP
+	normFrobenius
+	normNuclear
+)
+
+// NormOrder defines which norm is computed by Norm. Its interpretation
+// depends on the number of axes the norm is computed over, i.e., a vector
+// norm for one axis and a matrix norm for two axes
+type NormOrder struct {
+	kind normKind
+	p    float64
+}
+
+// NormP is the order p norm. For vectors it is (sum |x|^p)^(1/p) for
+// positive p, maximum of |x| for +Inf and minimum of |x| for -Inf.
+// For matrices p can be 1 or -1 for maximum or minimum absolute column
+// sum, 2 or -2 for largest or smallest singular value, and +Inf or -Inf
+// for maximum or minimum absolute row sum
+func NormP(p float64) NormOrder {
+	return NormOrder{kind: normP, p: p}
+}
+
+var (
+	// NormL1 is sum of absolute values for vectors and maximum
+	// absolute column sum for matrices
+	NormL1 = NormP(1)
+	// NormL2 is euclidean norm for vectors and largest singular
+	// value for matrices
+	NormL2 = NormP(2)
+	// NormLinf is maximum absolute value for vectors and maximum
+	// absolute row sum for matrices
+	NormLinf = NormP(math.Inf(1))
+	// NormFrobenius is square root of sum of squares of all
+	// elements of matrices
+	NormFrobenius = NormOrder{kind: normFrobenius}
+	// NormNuclear is sum of singular values of matrices
+	NormNuclear = NormOrder{kind: normNuclear}
+)
+
+// NormOption configures norm computed by Norm
+type NormOption func(*normOptions)
+
+type normOptions struct {
+	ord      NormOrder
+	axes     []int
+	keepDims bool
+}
+
+// NormOrd sets norm order. Default is euclidean norm over all elements
+// along norm axes, which is Frobenius norm for matrices
+func NormOrd(ord NormOrder) NormOption {
+	return func(o *normOptions) {
+		o.ord = ord
+	}
+}
+
+// NormAxes sets axes to compute norm over. One axis implies a vector
+// norm and two axes imply a matrix norm with rows along first axis and
+// columns along second axis. Negative axes count from the last
+// dimension. Default is all axes
+func NormAxes(axes ...int) NormOption {
+	return func(o *normOptions) {
+		o.axes = axes
+	}
+}
+
+// NormKeepDims sets retention of reduced axes with length 1
+func NormKeepDims(value bool) NormOption {
+	return func(o *normOptions) {
+		o.keepDims = value
+	}
+}
+
+func newNormOptions(options []NormOption) *normOptions {
+	o := &normOptions{}
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// Norm computes vector or matrix norm of input tensor. Output has input
+// shape with norm axes removed, or set to 1 when NormKeepDims is set.
+// When all axes are reduced output is a vector of length 1
+func Norm[T FloatTypes](input *Tensor[T], options ...NormOption) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	o := newNormOptions(options)
+
+	axes := o.axes
+	if axes == nil {
+		axes = make([]int, len(input.shape))
+		for i := range axes {
+			axes[i] = i
+		}
+	}
+
+	axes, _, err := splitAxes(axes, len(input.shape))
+	if err != nil {
+		return nil, fmt.Errorf("invalid norm axes: %w", err)
+	}
+
+	if len(axes) == 0 {
+		return nil, fmt.Errorf("norm needs at least one axis")
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+
+	var Output tf.Output
+	switch {
+	case o.ord.kind == normDefault:
+		Output = l2Norm(root, X, axes)
+	case len(axes) == 1:
+		Output, err = vectorNorm(root, X, axes[0], o.ord)
+	case len(axes) == 2:
+		Output, err = matrixNorm(root, X, input.shape, axes[0], axes[1], o.ord)
+	default:
+		err = fmt.Errorf("norm order needs one or two axes, got %d", len(axes))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !o.keepDims {
+		Output = op.Squeeze(root, Output, op.SqueezeAxis(castToInt64(axes)))
+	}
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// L2Normalize scales input such that its euclidean norm along axes is 1.
+// Default is all axes. Norms are bounded below by a small epsilon, so
+// that all zero slices remain zero
+func L2Normalize[T FloatTypes](input *Tensor[T], axes ...int) (*Tensor[T], error) {
+	return normalize(input, axes, func(scope *op.Scope, x, norm tf.Output) tf.Output {
+		epsilon := op.Cast(scope.SubScope("epsilon"), op.Const(scope.SubScope("epsilon"), 1e-12), x.DataType())
+		return op.Div(scope, x, op.Maximum(scope, norm, epsilon))
+	})
+}
+
+// ClipByNorm scales input such that its euclidean norm along axes does
+// not exceed clipNorm. Slices with norm already within clipNorm are left
+// unchanged. Default is all axes
+func ClipByNorm[T FloatTypes](input *Tensor[T], clipNorm T, axes ...int) (*Tensor[T], error) {
+	if clipNorm <= 0 {
+		return nil, fmt.Errorf("clipNorm needs to be positive, got %v", clipNorm)
+	}
+
+	return normalize(input, axes, func(scope *op.Scope, x, norm tf.Output) tf.Output {
+		clip := op.Const(scope.SubScope("clipNorm"), clipNorm)
+		return op.Div(scope, op.Mul(scope, x, clip), op.Maximum(scope, norm, clip))
+	})
+}
+
+// normalize rescales input using function f of input and its euclidean
+// norm along axes, the latter retaining reduced dimensions
+func normalize[T FloatTypes](input *Tensor[T], axes []int, f func(scope *op.Scope, x, norm tf.Output) tf.Output) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(axes) == 0 {
+		axes = make([]int, len(input.shape))
+		for i := range axes {
+			axes[i] = i
+		}
+	}
+
+	axes, _, err := splitAxes(axes, len(input.shape))
+	if err != nil {
+		return nil, fmt.Errorf("invalid axes: %w", err)
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	Output := f(root, X, l2Norm(root.SubScope("norm"), X, axes))
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// l2Norm computes euclidean norm of x over axes retaining reduced dimensions
+func l2Norm(scope *op.Scope, x tf.Output, axes []int) tf.Output {
+	return op.Sqrt(
+		scope,
+		op.Sum(
+			scope,
+			op.Square(scope, x),
+			op.Const(scope.SubScope("axes"), castToInt64(axes)),
+			op.SumKeepDims(true),
+		),
+	)
+}
+
+// vectorNorm computes vector norm of x along axis retaining reduced dimension
+func vectorNorm(scope *op.Scope, x tf.Output, axis int, ord NormOrder) (tf.Output, error) {
+	if ord.kind != normP {
+		return tf.Output{}, fmt.Errorf("vector norm needs order NormP, got a matrix norm order")
+	}
+
+	p := ord.p
+	axes := op.Const(scope.SubScope("axes"), []int64{int64(axis)})
+	abs := op.Abs(scope, x)
+
+	switch {
+	case math.IsInf(p, 1):
+		return op.Max(scope, abs, axes, op.MaxKeepDims(true)), nil
+	case math.IsInf(p, -1):
+		return op.Min(scope, abs, axes, op.MinKeepDims(true)), nil
+	case p == 1:
+		return op.Sum(scope, abs, axes, op.SumKeepDims(true)), nil
+	case p == 2:
+		return l2Norm(scope, x, []int{axis}), nil
+	case p > 0:
+		pow := op.Cast(scope.SubScope("p"), op.Const(scope.SubScope("p"), p), x.DataType())
+		inv := op.Cast(scope.SubScope("inv"), op.Const(scope.SubScope("inv"), 1/p), x.DataType())
+		return op.Pow(
+			scope,
+			op.Sum(scope, op.Pow(scope, abs, pow), axes, op.SumKeepDims(true)),
+			inv,
+		), nil
+	default:
+		return tf.Output{}, fmt.Errorf("vector norm order needs to be positive or infinite, got %v", p)
+	}
+}
+
+// matrixNorm computes matrix norm of x of input shape with rows along
+// axis r and columns along axis c retaining reduced dimensions
+func matrixNorm(scope *op.Scope, x tf.Output, shape []int, r, c int, ord NormOrder) (tf.Output, error) {
+	sumAbs := func(axis int) tf.Output {
+		return op.Sum(
+			scope,
+			op.Abs(scope, x),
+			op.Const(scope.SubScope("sumAxis"), []int64{int64(axis)}),
+			op.SumKeepDims(true),
+		)
+	}
+
+	switch ord.kind {
+	case normFrobenius:
+		return l2Norm(scope, x, []int{r, c}), nil
+	case normNuclear:
+		return singularValueNorm(scope, x, shape, r, c, func(scope *op.Scope, s, axis tf.Output) tf.Output {
+			return op.Sum(scope, s, axis)
+		}), nil
+	}
+
+	axis := func(axis int) tf.Output {
+		return op.Const(scope.SubScope("axis"), []int64{int64(axis)})
+	}
+
+	switch p := ord.p; {
+	case p == 1:
+		return op.Max(scope, sumAbs(r), axis(c), op.MaxKeepDims(true)), nil
+	case p == -1:
+		return op.Min(scope, sumAbs(r), axis(c), op.MinKeepDims(true)), nil
+	case math.IsInf(p, 1):
+		return op.Max(scope, sumAbs(c), axis(r), op.MaxKeepDims(true)), nil
+	case math.IsInf(p, -1):
+		return op.Min(scope, sumAbs(c), axis(r), op.MinKeepDims(true)), nil
+	case p == 2:
+		return singularValueNorm(scope, x, shape, r, c, func(scope *op.Scope, s, axis tf.Output) tf.Output {
+			return op.Max(scope, s, axis)
+		}), nil
+	case p == -2:
+		return singularValueNorm(scope, x, shape, r, c, func(scope *op.Scope, s, axis tf.Output) tf.Output {
+			return op.Min(scope, s, axis)
+		}), nil
+	default:
+		return tf.Output{}, fmt.Errorf("matrix norm order needs to be 1, 2, Inf or their negatives, got %v", p)
+	}
+}
+
+// singularValueNorm reduces singular values of matrices of x of input
+// shape with rows along axis r and columns along axis c using reduce
+// function, retaining reduced dimensions
+func singularValueNorm(
+	scope *op.Scope,
+	x tf.Output,
+	shape []int,
+	r, c int,
+	reduce func(scope *op.Scope, s, axis tf.Output) tf.Output,
+) tf.Output {
+	// move matrix axes innermost as expected by Svd
+	perm := make([]int64, 0, len(shape))
+	for i := range shape {
+		if i != r && i != c {
+			perm = append(perm, int64(i))
+		}
+	}
+	perm = append(perm, int64(r), int64(c))
+
+	s, _, _ := op.Svd(
+		scope,
+		op.Transpose(scope, x, op.Const(scope.SubScope("perm"), perm)),
+		op.SvdComputeUv(false),
+	)
+
+	keepShape := castToInt64(shape)
+	keepShape[r], keepShape[c] = 1, 1
+
+	return op.Reshape(
+		scope,
+		reduce(scope, s, op.Const(scope.SubScope("reduceAxis"), int32(-1))),
+		op.Const(scope.SubScope("shape"), keepShape),
+	)
+}
@@ -0,0 +1,138 @@
+package tfutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormVector(t *testing.T) {
+	x, err := NewTensor([]float64{3, -4, 0, 1, 2, -2}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l2, err := Norm(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(l2.value, []float64{math.Sqrt(34)}, 1e-12) || !equal(l2.shape, []int{1}) {
+		t.Fatal("l2 norm does not match expected value")
+	}
+
+	l1, err := Norm(x, NormOrd(NormL1), NormAxes(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(l1.value, []float64{7, 5}, 1e-12) || !equal(l1.shape, []int{2}) {
+		t.Fatal("l1 norm does not match expected value")
+	}
+
+	linf, err := Norm(x, NormOrd(NormLinf), NormAxes(0), NormKeepDims(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(linf.value, []float64{3, 4, 2}, 1e-12) || !equal(linf.shape, []int{1, 3}) {
+		t.Fatal("linf norm does not match expected value")
+	}
+
+	p3, err := Norm(x, NormOrd(NormP(3)), NormAxes(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(p3.value, []float64{math.Cbrt(91), math.Cbrt(17)}, 1e-9) {
+		t.Fatal("p norm does not match expected value")
+	}
+
+	if _, err := Norm(x, NormOrd(NormP(-1)), NormAxes(1)); err == nil {
+		t.Fatal("expected norm to fail for negative vector order")
+	}
+
+	if _, err := Norm(x, NormOrd(NormFrobenius), NormAxes(1)); err == nil {
+		t.Fatal("expected norm to fail for matrix order over single axis")
+	}
+}
+
+func TestNormMatrix(t *testing.T) {
+	x, err := NewTensor([]float32{1, -2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ord      NormOrder
+		expected float32
+	}{
+		{NormFrobenius, float32(math.Sqrt(30))},
+		{NormL1, 6},
+		{NormLinf, 7},
+		{NormP(-1), 4},
+		{NormL2, 5.1167},
+		{NormNuclear, 5.1167 + 1.9544},
+	}
+
+	for _, test := range tests {
+		norm, err := Norm(x, NormOrd(test.ord))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !allClose(norm.value, []float32{test.expected}, 1e-3) {
+			t.Fatalf("norm with order %v does not match expected value, got %v", test.ord, norm.value)
+		}
+	}
+
+	// matrix axes need not be innermost
+	y, err := NewTensor([]float32{1, 3, -2, 4}, 2, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	norm, err := Norm(y, NormOrd(NormL1), NormAxes(2, 0), NormKeepDims(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(norm.value, []float32{6}, 1e-6) || !equal(norm.shape, []int{1, 1, 1}) {
+		t.Fatal("norm over outer axes does not match expected value")
+	}
+}
+
+func TestL2Normalize(t *testing.T) {
+	x, err := NewTensor([]float64{3, 4, 0, 0}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := L2Normalize(x, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(y.value, []float64{0.6, 0.8, 0, 0}, 1e-12) || !equal(y.shape, []int{2, 2}) {
+		t.Fatal("l2 normalized output does not match expected value")
+	}
+}
+
+func TestClipByNorm(t *testing.T) {
+	x, err := NewTensor([]float64{3, 4, 0.3, 0.4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := ClipByNorm(x, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(y.value, []float64{0.6, 0.8, 0.3, 0.4}, 1e-12) {
+		t.Fatal("clipped output does not match expected value")
+	}
+
+	if _, err := ClipByNorm(x, 0); err == nil {
+		t.Fatal("expected clip by norm to fail for non-positive clip norm")
+	}
+}
@@ -0,0 +1,434 @@
+package tfutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// npyMagic prefixes every npy file
+const npyMagic = "\x93NUMPY"
+
+// npyChunkSize is the number of values read at a time, so that memory
+// grows with data actually read rather than with shape in the header
+const npyChunkSize = 1 << 16
+
+var (
+	npyDescrRegexp   = regexp.MustCompile(`['"]descr['"]\s*:\s*['"]([^'"]*)['"]`)
+	npyFortranRegexp = regexp.MustCompile(`['"]fortran_order['"]\s*:\s*(True|False)`)
+	npyShapeRegexp   = regexp.MustCompile(`['"]shape['"]\s*:\s*\(([^)]*)\)`)
+)
+
+// npyHeader is parsed header of an npy file
+type npyHeader struct {
+	order        binary.ByteOrder
+	kind         byte
+	size         int
+	fortranOrder bool
+	shape        []int
+}
+
+// ReadNpy reads a tensor from NumPy npy format. Data type of the array
+// needs to match T, with unicode and bytes arrays read as strings with
+// trailing null characters removed. Arrays in Fortran order and in either
+// byte order are supported. A zero dimensional array is read as a vector
+// of length 1
+func ReadNpy[T PrimitiveTypes](r io.Reader) (*Tensor[T], error) {
+	header, err := readNpyHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkNpyDataType[T](header); err != nil {
+		return nil, err
+	}
+
+	shape := header.shape
+	if len(shape) == 0 {
+		shape = []int{1}
+	}
+
+	n, err := numElements(shape)
+	if err != nil {
+		return nil, fmt.Errorf("invalid npy shape %v: %w", header.shape, err)
+	}
+
+	values, err := readNpyValues[T](r, header, n)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fortran order is row-major order of reversed shape, which
+	// is transposed back to shape
+	if header.fortranOrder && len(shape) > 1 {
+		reversed := make([]int, len(shape))
+		perm := make([]int, len(shape))
+		for i := range shape {
+			reversed[i] = shape[len(shape)-1-i]
+			perm[i] = len(shape) - 1 - i
+		}
+		values = transposeValues(values, reversed, perm)
+	}
+
+	return NewTensor(values, shape...)
+}
+
+// WriteNpy writes tensor in NumPy npy format version 1.0, or 2.0 if header
+// does not fit version 1.0, in C order and little endian byte order.
+// Strings are written as unicode arrays
+func WriteNpy[T PrimitiveTypes](w io.Writer, tensor *Tensor[T]) error {
+	if tensor == nil {
+		return fmt.Errorf("tensor can't be nil")
+	}
+
+	descr, err := npyDescr(tensor.value)
+	if err != nil {
+		return err
+	}
+
+	dims := make([]string, len(tensor.shape))
+	for i, dim := range tensor.shape {
+		dims[i] = strconv.Itoa(dim)
+	}
+
+	shape := strings.Join(dims, ", ")
+	if len(dims) == 1 {
+		shape += ","
+	}
+
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shape)
+
+	// header is padded with spaces and terminated by a newline
+	// such that data starts at a multiple of 64 bytes
+	version, prefix := byte(1), len(npyMagic)+2+2
+	if len(header)+1+prefix+64 > 1<<16 {
+		version, prefix = 2, len(npyMagic)+2+4
+	}
+	header += strings.Repeat(" ", 63-(prefix+len(header))%64) + "\n"
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{version, 0})
+	if version == 1 {
+		_ = binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	} else {
+		_ = binary.Write(buf, binary.LittleEndian, uint32(len(header)))
+	}
+	buf.WriteString(header)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write npy header: %w", err)
+	}
+
+	if err := writeNpyValues(w, tensor.value); err != nil {
+		return fmt.Errorf("failed to write npy data: %w", err)
+	}
+
+	return nil
+}
+
+// ReadNpz reads named tensors from NumPy npz format, which is a zip archive
+// of npy files, either stored or compressed, of given size. Names are file
+// names in the archive without .npy extension. All arrays need data type
+// matching T, see ReadNpy
+func ReadNpz[T PrimitiveTypes](r io.ReaderAt, size int64) (map[string]*Tensor[T], error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read npz archive: %w", err)
+	}
+
+	tensors := make(map[string]*Tensor[T], len(archive.File))
+	for _, file := range archive.File {
+		name := strings.TrimSuffix(file.Name, ".npy")
+
+		tensor, err := func() (*Tensor[T], error) {
+			f, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+
+			return ReadNpy[T](f)
+		}()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read array %q: %w", name, err)
+		}
+
+		tensors[name] = tensor
+	}
+
+	return tensors, nil
+}
+
+// WriteNpz writes named tensors in NumPy npz format without compression,
+// which matches numpy.savez. Arrays are written in sorted order of names
+func WriteNpz[T PrimitiveTypes](w io.Writer, tensors map[string]*Tensor[T]) error {
+	names := make([]string, 0, len(tensors))
+	for name := range tensors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return fmt.Errorf("failed to create npz entry for array %q: %w", name, err)
+		}
+
+		if err := WriteNpy(f, tensors[name]); err != nil {
+			return fmt.Errorf("failed to write array %q: %w", name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to close npz archive: %w", err)
+	}
+
+	return nil
+}
+
+// readNpyHeader reads and parses magic, version and header of npy file
+func readNpyHeader(r io.Reader) (*npyHeader, error) {
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("failed to read npy magic: %w", err)
+	}
+
+	if string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, fmt.Errorf("invalid npy magic %q", prefix[:len(npyMagic)])
+	}
+
+	var headerLen int
+	switch version := prefix[len(npyMagic)]; version {
+	case 1:
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read npy header length: %w", err)
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read npy header length: %w", err)
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d", version)
+	}
+
+	b := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("failed to read npy header: %w", err)
+	}
+
+	return parseNpyHeader(string(b))
+}
+
+// parseNpyHeader parses python dict literal of npy header
+func parseNpyHeader(s string) (*npyHeader, error) {
+	descr := npyDescrRegexp.FindStringSubmatch(s)
+	fortran := npyFortranRegexp.FindStringSubmatch(s)
+	shape := npyShapeRegexp.FindStringSubmatch(s)
+	if descr == nil || fortran == nil || shape == nil {
+		return nil, fmt.Errorf("invalid npy header %q", strings.TrimSpace(s))
+	}
+
+	header := &npyHeader{fortranOrder: fortran[1] == "True"}
+
+	d := descr[1]
+	if len(d) < 3 {
+		return nil, fmt.Errorf("unsupported npy dtype %q", d)
+	}
+
+	switch d[0] {
+	case '<', '|':
+		header.order = binary.LittleEndian
+	case '>':
+		header.order = binary.BigEndian
+	case '=':
+		header.order = binary.NativeEndian
+	default:
+		return nil, fmt.Errorf("unsupported npy dtype %q", d)
+	}
+
+	header.kind = d[1]
+	size, err := strconv.Atoi(d[2:])
+	if err != nil || size <= 0 || size > math.MaxInt/4 {
+		return nil, fmt.Errorf("unsupported npy dtype %q", d)
+	}
+	header.size = size
+
+	for _, dim := range strings.Split(shape[1], ",") {
+		dim = strings.TrimSpace(dim)
+		if dim == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(dim, "L"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid npy shape %q: %w", shape[1], err)
+		}
+		header.shape = append(header.shape, n)
+	}
+
+	return header, nil
+}
+
+// checkNpyDataType checks that dtype of npy header matches T
+func checkNpyDataType[T PrimitiveTypes](header *npyHeader) error {
+	var kind byte
+	var size int
+	switch any(*new(T)).(type) {
+	case string:
+		if header.kind == 'U' || header.kind == 'S' {
+			return nil
+		}
+		kind = 'U'
+	case bool:
+		kind, size = 'b', 1
+	case int8, int16, int32, int64:
+		kind, size = 'i', binary.Size(*new(T))
+	case uint8, uint16, uint32, uint64:
+		kind, size = 'u', binary.Size(*new(T))
+	case float32, float64:
+		kind, size = 'f', binary.Size(*new(T))
+	case complex64, complex128:
+		kind, size = 'c', binary.Size(*new(T))
+	}
+
+	if header.kind != kind || header.size != size {
+		return fmt.Errorf(
+			"npy dtype %c%d does not match data type %T, which needs %c%d",
+			header.kind, header.size, *new(T), kind, size,
+		)
+	}
+
+	return nil
+}
+
+// npyDescr returns npy dtype descriptor for values, which for strings is
+// a unicode array wide enough for the longest string
+func npyDescr[T PrimitiveTypes](values []T) (string, error) {
+	switch v := any(values).(type) {
+	case []string:
+		width := 1
+		for _, s := range v {
+			width = max(width, utf8.RuneCountInString(s))
+		}
+		return fmt.Sprintf("<U%d", width), nil
+	case []bool:
+		return "|b1", nil
+	case []int8:
+		return "|i1", nil
+	case []uint8:
+		return "|u1", nil
+	}
+
+	var kind byte
+	switch any(*new(T)).(type) {
+	case int16, int32, int64:
+		kind = 'i'
+	case uint16, uint32, uint64:
+		kind = 'u'
+	case float32, float64:
+		kind = 'f'
+	case complex64, complex128:
+		kind = 'c'
+	default:
+		return "", fmt.Errorf("unsupported data type %T", *new(T))
+	}
+
+	return fmt.Sprintf("<%c%d", kind, binary.Size(*new(T))), nil
+}
+
+// readNpyValues reads n values of npy data in chunks, since n comes
+// from a possibly corrupt header
+func readNpyValues[T PrimitiveTypes](r io.Reader, header *npyHeader, n int) ([]T, error) {
+	if _, ok := any(*new(T)).(string); !ok {
+		values := make([]T, 0, min(n, npyChunkSize))
+		chunk := make([]T, min(n, npyChunkSize))
+		for len(values) < n {
+			k := min(n-len(values), len(chunk))
+			if err := binary.Read(r, header.order, chunk[:k]); err != nil {
+				return nil, fmt.Errorf("failed to read npy data: %w", err)
+			}
+			values = append(values, chunk[:k]...)
+		}
+
+		return values, nil
+	}
+
+	// unicode arrays hold fixed width UTF-32 code points and bytes
+	// arrays hold fixed width bytes, both padded with nulls
+	width := header.size
+	if header.kind == 'U' {
+		width *= 4
+	}
+
+	var b []byte
+	strs := make([]string, 0, min(n, npyChunkSize))
+	for len(strs) < n {
+		if b == nil {
+			// first string is copied rather than preallocated so
+			// that a corrupt width can't trigger a huge allocation
+			buf := &bytes.Buffer{}
+			if _, err := io.CopyN(buf, r, int64(width)); err != nil {
+				return nil, fmt.Errorf("failed to read npy data: %w", err)
+			}
+			b = buf.Bytes()
+		} else if _, err := io.ReadFull(r, b); err != nil {
+			return nil, fmt.Errorf("failed to read npy data: %w", err)
+		}
+
+		if header.kind == 'S' {
+			strs = append(strs, strings.TrimRight(string(b), "\x00"))
+			continue
+		}
+
+		runes := make([]rune, 0, header.size)
+		for j := 0; j < width; j += 4 {
+			runes = append(runes, rune(header.order.Uint32(b[j:])))
+		}
+		strs = append(strs, strings.TrimRight(string(runes), "\x00"))
+	}
+
+	return any(strs).([]T), nil
+}
+
+// writeNpyValues writes values as npy data in little endian byte order
+func writeNpyValues[T PrimitiveTypes](w io.Writer, values []T) error {
+	strs, ok := any(values).([]string)
+	if !ok {
+		return binary.Write(w, binary.LittleEndian, values)
+	}
+
+	width := 1
+	for _, s := range strs {
+		width = max(width, utf8.RuneCountInString(s))
+	}
+
+	b := make([]byte, 4*width)
+	for _, s := range strs {
+		clear(b)
+		offset := 0
+		for _, r := range s {
+			binary.LittleEndian.PutUint32(b[offset:], uint32(r))
+			offset += 4
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package tfutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// newNpy builds a version 1.0 npy file with given header and data
+func newNpy(header string, data []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	_ = binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestNpyRoundTrip(t *testing.T) {
+	input, err := NewTensor([]float64{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteNpy(buf, input); err != nil {
+		t.Fatal(err)
+	}
+
+	// data starts at a multiple of 64 bytes
+	if (buf.Len()-48)%64 != 0 || !strings.Contains(buf.String(), "'descr': '<f8'") {
+		t.Fatalf("unexpected npy header %q", buf.String()[:buf.Len()-48])
+	}
+
+	output, err := ReadNpy[float64](buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(output.value, input.value) || !equal(output.shape, input.shape) {
+		t.Fatal("npy round trip does not match input")
+	}
+
+	strs, err := NewTensor([]string{"a", "héllo", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := WriteNpy(buf, strs); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "'descr': '<U5'") || !strings.Contains(buf.String(), "'shape': (3,)") {
+		t.Fatal("unexpected npy header for strings")
+	}
+
+	strsOut, err := ReadNpy[string](buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(strsOut.value, strs.value) {
+		t.Fatalf("npy round trip of strings does not match input, got %q", strsOut.value)
+	}
+
+	complexes, err := NewTensor([]complex64{1 + 2i, 3 - 4i})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := WriteNpy(buf, complexes); err != nil {
+		t.Fatal(err)
+	}
+
+	complexesOut, err := ReadNpy[complex64](buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(complexesOut.value, complexes.value) {
+		t.Fatal("npy round trip of complex values does not match input")
+	}
+}
+
+func TestReadNpy(t *testing.T) {
+	// big endian int32 matrix [[1, 2, 3], [4, 5, 6]] in Fortran order
+	data := &bytes.Buffer{}
+	_ = binary.Write(data, binary.BigEndian, []int32{1, 4, 2, 5, 3, 6})
+	b := newNpy("{'descr': '>i4', 'fortran_order': True, 'shape': (2, 3), }\n", data.Bytes())
+
+	x, err := ReadNpy[int32](bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(x.value, []int32{1, 2, 3, 4, 5, 6}) || !equal(x.shape, []int{2, 3}) {
+		t.Fatalf("unexpected value %v of shape %v", x.value, x.shape)
+	}
+
+	// bytes array with null padding
+	b = newNpy("{'descr': '|S3', 'fortran_order': False, 'shape': (2,), }\n", []byte("ab\x00xyz"))
+	s, err := ReadNpy[string](bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(s.value, []string{"ab", "xyz"}) {
+		t.Fatalf("unexpected value %q", s.value)
+	}
+
+	// zero dimensional array
+	data.Reset()
+	_ = binary.Write(data, binary.LittleEndian, 2.5)
+	b = newNpy("{'descr': '<f8', 'fortran_order': False, 'shape': (), }\n", data.Bytes())
+	scalar, err := ReadNpy[float64](bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(scalar.value, []float64{2.5}) || !equal(scalar.shape, []int{1}) {
+		t.Fatal("unexpected value of zero dimensional array")
+	}
+
+	_, err = ReadNpy[float32](bytes.NewReader(b))
+	if err == nil || !strings.Contains(err.Error(), "f8 does not match data type float32") {
+		t.Fatalf("expected dtype mismatch error, got %v", err)
+	}
+
+	if _, err := ReadNpy[float64](strings.NewReader("not an npy file")); err == nil {
+		t.Fatal("expected read npy to fail for invalid magic")
+	}
+	// shapes from corrupt headers neither overflow nor allocate
+	// memory for data that is not present
+	b = newNpy("{'descr': '<i8', 'fortran_order': False, 'shape': (3037000500, 3037000500), }\n", nil)
+	if _, err := ReadNpy[int64](bytes.NewReader(b)); err == nil {
+		t.Fatal("expected read npy to fail for overflowing shape")
+	}
+
+	b = newNpy("{'descr': '<i8', 'fortran_order': False, 'shape': (1099511627776,), }\n", make([]byte, 16))
+	if _, err := ReadNpy[int64](bytes.NewReader(b)); err == nil {
+		t.Fatal("expected read npy to fail for truncated data")
+	}
+
+	b = newNpy("{'descr': '<U1099511627776', 'fortran_order': False, 'shape': (2,), }\n", make([]byte, 16))
+	if _, err := ReadNpy[string](bytes.NewReader(b)); err == nil {
+		t.Fatal("expected read npy to fail for truncated strings")
+	}
+}
+
+func TestNpz(t *testing.T) {
+	x, err := NewTensor([]int64{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]int64{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteNpz(buf, map[string]*Tensor[int64]{"x": x, "y": y}); err != nil {
+		t.Fatal(err)
+	}
+
+	tensors, err := ReadNpz[int64](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tensors) != 2 || !equal(tensors["x"].value, x.value) || !equal(tensors["x"].shape, x.shape) ||
+		!equal(tensors["y"].value, y.value) {
+		t.Fatal("npz round trip does not match input")
+	}
+
+	_, err = ReadNpz[int32](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err == nil || !strings.Contains(err.Error(), `array "x"`) {
+		t.Fatalf("expected dtype mismatch error naming array, got %v", err)
+	}
+}
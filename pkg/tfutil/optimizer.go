@@ -0,0 +1,499 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+type optimizerKind int
+
+const (
+	optimizerSGD optimizerKind = iota
+	optimizerAdam
+	optimizerRMSProp
+	optimizerAdagrad
+)
+
+// OptimizerOption configures hyperparameters of an optimizer. Options
+// that do not apply to an optimizer are ignored
+type OptimizerOption func(*optimizerOptions)
+
+type optimizerOptions struct {
+	momentum           float64
+	nesterov           bool
+	beta1              float64
+	beta2              float64
+	epsilon            float64
+	rho                float64
+	initialAccumulator float64
+}
+
+// OptimizerMomentum sets momentum for SGD and RMSProp. Default is 0,
+// i.e., no momentum
+func OptimizerMomentum(value float64) OptimizerOption {
+	return func(o *optimizerOptions) {
+		o.momentum = value
+	}
+}
+
+// OptimizerNesterov sets use of Nesterov momentum for SGD
+func OptimizerNesterov(value bool) OptimizerOption {
+	return func(o *optimizerOptions) {
+		o.nesterov = value
+	}
+}
+
+// OptimizerBeta1 sets decay rate of first moment estimates for Adam.
+// Default is 0.9
+func OptimizerBeta1(value float64) OptimizerOption {
+	return func(o *optimizerOptions) {
+		o.beta1 = value
+	}
+}
+
+// OptimizerBeta2 sets decay rate of second moment estimates for Adam.
+// Default is 0.999
+func OptimizerBeta2(value float64) OptimizerOption {
+	return func(o *optimizerOptions) {
+		o.beta2 = value
+	}
+}
+
+// OptimizerEpsilon sets constant for numerical stability for Adam and
+// RMSProp. Default is 1e-7
+func OptimizerEpsilon(value float64) OptimizerOption {
+	return func(o *optimizerOptions) {
+		o.epsilon = value
+	}
+}
+
+// OptimizerRho sets decay rate of moving average of squared gradients
+// for RMSProp. Default is 0.9
+func OptimizerRho(value float64) OptimizerOption {
+	return func(o *optimizerOptions) {
+		o.rho = value
+	}
+}
+
+// OptimizerInitialAccumulator sets starting value of accumulators for
+// Adagrad. Default is 0.1
+func OptimizerInitialAccumulator(value float64) OptimizerOption {
+	return func(o *optimizerOptions) {
+		o.initialAccumulator = value
+	}
+}
+
+func newOptimizerOptions(options []OptimizerOption) *optimizerOptions {
+	o := &optimizerOptions{
+		beta1:              0.9,
+		beta2:              0.999,
+		epsilon:            1e-7,
+		rho:                0.9,
+		initialAccumulator: 0.1,
+	}
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// Optimizer updates variables given their gradients using tensorflow
+// ResourceApply* kernels. Optimizer state, such as momentum accumulators,
+// is kept per variable in variables created in the session of that
+// variable. An optimizer is not safe for concurrent use
+type Optimizer[T FloatTypes] struct {
+	kind         optimizerKind
+	learningRate float64
+	options      *optimizerOptions
+	states       map[*Variable[T]]*optimizerState[T]
+}
+
+// optimizerState holds slot variables of an optimizer for a variable
+// and update op fed by gradient placeholder used by Apply
+type optimizerState[T FloatTypes] struct {
+	slots    []*Variable[T]
+	gradient tf.Output
+	update   *tf.Operation
+}
+
+// NewSGD creates a stochastic gradient descent optimizer, optionally with
+// momentum, see OptimizerMomentum and OptimizerNesterov
+func NewSGD[T FloatTypes](learningRate float64, options ...OptimizerOption) (*Optimizer[T], error) {
+	return newOptimizer[T](optimizerSGD, learningRate, options)
+}
+
+// NewAdam creates an Adam optimizer, see OptimizerBeta1, OptimizerBeta2
+// and OptimizerEpsilon
+func NewAdam[T FloatTypes](learningRate float64, options ...OptimizerOption) (*Optimizer[T], error) {
+	return newOptimizer[T](optimizerAdam, learningRate, options)
+}
+
+// NewRMSProp creates an RMSProp optimizer, see OptimizerRho,
+// OptimizerMomentum and OptimizerEpsilon
+func NewRMSProp[T FloatTypes](learningRate float64, options ...OptimizerOption) (*Optimizer[T], error) {
+	return newOptimizer[T](optimizerRMSProp, learningRate, options)
+}
+
+// NewAdagrad creates an Adagrad optimizer, see OptimizerInitialAccumulator
+func NewAdagrad[T FloatTypes](learningRate float64, options ...OptimizerOption) (*Optimizer[T], error) {
+	return newOptimizer[T](optimizerAdagrad, learningRate, options)
+}
+
+func newOptimizer[T FloatTypes](kind optimizerKind, learningRate float64, options []OptimizerOption) (*Optimizer[T], error) {
+	if learningRate <= 0 {
+		return nil, fmt.Errorf("learning rate needs to be positive, got %v", learningRate)
+	}
+
+	o := newOptimizerOptions(options)
+	for _, rate := range []struct {
+		name  string
+		value float64
+	}{
+		{"momentum", o.momentum},
+		{"beta1", o.beta1},
+		{"beta2", o.beta2},
+		{"rho", o.rho},
+	} {
+		if rate.value < 0 || rate.value >= 1 {
+			return nil, fmt.Errorf("%s needs to be in [0, 1), got %v", rate.name, rate.value)
+		}
+	}
+
+	if o.epsilon <= 0 {
+		return nil, fmt.Errorf("epsilon needs to be positive, got %v", o.epsilon)
+	}
+
+	if o.initialAccumulator < 0 {
+		return nil, fmt.Errorf("initial accumulator can't be negative, got %v", o.initialAccumulator)
+	}
+
+	return &Optimizer[T]{
+		kind:         kind,
+		learningRate: learningRate,
+		options:      o,
+		states:       make(map[*Variable[T]]*optimizerState[T]),
+	}, nil
+}
+
+// Apply updates variables in place given gradients of matching shapes,
+// for instance, as computed by Gradient. All variables need to belong to
+// the same session and are updated in a single session run
+func (o *Optimizer[T]) Apply(variables []*Variable[T], gradients []*Tensor[T]) error {
+	if len(variables) != len(gradients) {
+		return fmt.Errorf("expected %d gradients for %d variables, got %d", len(variables), len(variables), len(gradients))
+	}
+
+	if len(variables) == 0 {
+		return nil
+	}
+
+	session, err := sessionOf(variables)
+	if err != nil {
+		return err
+	}
+
+	feeds := make(map[tf.Output]*tf.Tensor)
+	targets := make([]*tf.Operation, len(variables))
+	for i, variable := range variables {
+		x, err := variable.marshalValue(gradients[i])
+		if err != nil {
+			return fmt.Errorf("invalid gradient %d: %w", i, err)
+		}
+
+		state, err := o.state(variable)
+		if err != nil {
+			return err
+		}
+
+		if state.update == nil {
+			if err := func() error {
+				session.mu.Lock()
+				defer session.mu.Unlock()
+
+				return session.extend("optimizer", func(scope *op.Scope) {
+					state.gradient = placeholder(scope, "gradient", x)
+					state.update = o.update(scope, variable, state.slots, state.gradient)
+				})
+			}(); err != nil {
+				return err
+			}
+		}
+
+		feeds[state.gradient] = x
+		targets[i] = state.update
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if _, err := session.run(feeds, nil, targets...); err != nil {
+		return fmt.Errorf("failed to apply gradients: %w", err)
+	}
+
+	return nil
+}
+
+// state returns optimizer state for variable creating its slot
+// variables on first use
+func (o *Optimizer[T]) state(variable *Variable[T]) (*optimizerState[T], error) {
+	if state, ok := o.states[variable]; ok {
+		return state, nil
+	}
+
+	// initial values of slots in the order expected by update
+	var initial []T
+	switch o.kind {
+	case optimizerSGD:
+		if o.options.momentum > 0 {
+			initial = []T{0}
+		}
+	case optimizerAdam:
+		initial = []T{0, 0}
+	case optimizerRMSProp:
+		initial = []T{0, 0}
+	case optimizerAdagrad:
+		initial = []T{T(o.options.initialAccumulator)}
+	}
+
+	state := &optimizerState[T]{}
+	for _, value := range initial {
+		slot, err := NewTensorFromFunc(func(int) T { return value }, variable.shape...)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := state.addSlot(variable.session, slot); err != nil {
+			return nil, err
+		}
+	}
+
+	// powers of betas, which are updated after each step for
+	// bias correction of moment estimates
+	if o.kind == optimizerAdam {
+		for _, beta := range []float64{o.options.beta1, o.options.beta2} {
+			power, err := NewTensor([]T{T(beta)})
+			if err != nil {
+				return nil, err
+			}
+
+			if err := state.addSlot(variable.session, power); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	o.states[variable] = state
+	return state, nil
+}
+
+// addSlot adds a slot variable initialized to initial
+func (s *optimizerState[T]) addSlot(session *Session, initial *Tensor[T]) error {
+	slot, err := NewVariable(session, initial)
+	if err != nil {
+		return fmt.Errorf("failed to create optimizer slot: %w", err)
+	}
+
+	s.slots = append(s.slots, slot)
+	return nil
+}
+
+// update adds an op updating variable given gradient output
+func (o *Optimizer[T]) update(scope *op.Scope, variable *Variable[T], slots []*Variable[T], gradient tf.Output) *tf.Operation {
+	constant := func(name string, value float64) tf.Output {
+		return op.Const(scope.SubScope(name), T(value))
+	}
+
+	lr := constant("learningRate", o.learningRate)
+	switch o.kind {
+	case optimizerSGD:
+		if o.options.momentum == 0 {
+			return op.ResourceApplyGradientDescent(scope, variable.handle, lr, gradient)
+		}
+
+		return op.ResourceApplyMomentum(
+			scope,
+			variable.handle,
+			slots[0].handle,
+			lr,
+			gradient,
+			constant("momentum", o.options.momentum),
+			op.ResourceApplyMomentumUseNesterov(o.options.nesterov),
+		)
+	case optimizerAdam:
+		beta1, beta2 := constant("beta1", o.options.beta1), constant("beta2", o.options.beta2)
+		powers := make([]tf.Output, 2)
+		for i := range powers {
+			powers[i] = op.Reshape(
+				scope,
+				op.ReadVariableOp(scope, slots[2+i].handle, slots[2+i].read.DataType()),
+				op.Const(scope.SubScope("scalar"), []int32{}),
+			)
+		}
+
+		apply := op.ResourceApplyAdam(
+			scope,
+			variable.handle,
+			slots[0].handle,
+			slots[1].handle,
+			powers[0],
+			powers[1],
+			lr,
+			beta1,
+			beta2,
+			constant("epsilon", o.options.epsilon),
+			gradient,
+		)
+
+		// powers are advanced once kernel has read them
+		after := scope.WithControlDependencies(apply)
+		ops := []*tf.Operation{apply}
+		for i, beta := range []tf.Output{beta1, beta2} {
+			ops = append(ops, op.AssignVariableOp(
+				after,
+				slots[2+i].handle,
+				op.Reshape(after, op.Mul(after, powers[i], beta), op.Const(after.SubScope("shape"), []int32{1})),
+			))
+		}
+
+		return op.NoOp(scope.WithControlDependencies(ops...))
+	case optimizerRMSProp:
+		return op.ResourceApplyRMSProp(
+			scope,
+			variable.handle,
+			slots[0].handle,
+			slots[1].handle,
+			lr,
+			constant("rho", o.options.rho),
+			constant("momentum", o.options.momentum),
+			constant("epsilon", o.options.epsilon),
+			gradient,
+		)
+	default:
+		return op.ResourceApplyAdagrad(scope, variable.handle, slots[0].handle, lr, gradient)
+	}
+}
+
+// Fit minimizes loss over variables by running steps of optimizer and
+// returns loss value at each step prior to the update of that step. loss
+// is an operator applied over current values of variables followed by
+// inputs, which are fed unchanged to each step. A loss output that is not
+// a scalar is summed. Gradients are computed within the session, see
+// Gradient for details. Each call adds loss, gradient and update ops to
+// the graph of the session owning variables, which grows with every call
+// for as long as the session is open. Training should hence run many
+// steps per call rather than call Fit once per step
+func Fit[T FloatTypes](
+	loss Operator,
+	optimizer *Optimizer[T],
+	variables []*Variable[T],
+	steps int,
+	inputs ...*Tensor[T],
+) ([]T, error) {
+	if optimizer == nil {
+		return nil, fmt.Errorf("optimizer can't be nil")
+	}
+
+	if len(variables) == 0 {
+		return nil, fmt.Errorf("at least one variable is needed")
+	}
+
+	if steps < 0 {
+		return nil, fmt.Errorf("steps can't be negative, got %d", steps)
+	}
+
+	session, err := sessionOf(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]*optimizerState[T], len(variables))
+	for i, variable := range variables {
+		if states[i], err = optimizer.state(variable); err != nil {
+			return nil, err
+		}
+	}
+
+	feeds := make(map[tf.Output]*tf.Tensor)
+	tfTensors := make([]*tf.Tensor, len(inputs))
+	for i, input := range inputs {
+		if input == nil {
+			return nil, fmt.Errorf("input %d can't be nil", i)
+		}
+
+		if tfTensors[i], err = input.Marshal(); err != nil {
+			return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+		}
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	var output tf.Output
+	var train *tf.Operation
+	var lossErr error // error of loss or its gradients
+	if err := session.extend("fit", func(scope *op.Scope) {
+		values := make([]tf.Output, 0, len(variables)+len(inputs))
+		for _, variable := range variables {
+			values = append(values, op.ReadVariableOp(scope, variable.handle, variable.read.DataType()))
+		}
+
+		for i, x := range tfTensors {
+			X := placeholder(scope, fmt.Sprintf("T%d", i), x)
+			feeds[X] = x
+			values = append(values, X)
+		}
+
+		if output, lossErr = loss(scope.SubScope("loss"), values...); lossErr != nil {
+			return
+		}
+
+		output = op.Sum(
+			scope,
+			output,
+			op.Range(
+				scope,
+				op.Const(scope.SubScope("start"), int32(0)),
+				op.Rank(scope, output),
+				op.Const(scope.SubScope("delta"), int32(1)),
+			),
+		)
+
+		grads, err := gradients(scope.SubScope("gradients"), []tf.Output{output}, values[:len(variables)])
+		if err != nil {
+			lossErr = err
+			return
+		}
+
+		updates := make([]*tf.Operation, len(variables))
+		for i, variable := range variables {
+			updates[i] = optimizer.update(scope.SubScope("optimizer"), variable, states[i].slots, grads[i])
+		}
+
+		train = op.NoOp(scope.WithControlDependencies(updates...))
+	}); err != nil {
+		return nil, err
+	}
+
+	if lossErr != nil {
+		return nil, fmt.Errorf("invalid loss: %w", lossErr)
+	}
+
+	losses := make([]T, steps)
+	for step := range losses {
+		out, err := session.run(feeds, []tf.Output{output}, train)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run step %d: %w", step, err)
+		}
+
+		value, ok := out[0].Value().(T)
+		if !ok {
+			return nil, fmt.Errorf("expected loss of data type %T, got %T", *new(T), out[0].Value())
+		}
+		losses[step] = value
+	}
+
+	return losses, nil
+}
@@ -0,0 +1,206 @@
+package tfutil
+
+import (
+	"math"
+	"testing"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// meanSquaredErrorOp computes mean squared error of linear model with
+// weight and bias fitted to inputs x and targets y
+var meanSquaredErrorOp = func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+	w, b, x, y := outputs[0], outputs[1], outputs[2], outputs[3]
+	residual := op.Sub(scope, op.Add(scope, op.Mul(scope, w, x), b), y)
+	return op.Mean(scope, op.Square(scope, residual), op.Const(scope.SubScope("axes"), []int32{0})), nil
+}
+
+// applySteps applies optimizer to a variable initialized to zeros with
+// a constant gradient and returns variable value after each step
+func applySteps(t *testing.T, optimizer *Optimizer[float64], gradient []float64, steps int) [][]float64 {
+	session, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err := session.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	initial, err := NewTensor(make([]float64, len(gradient)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variable, err := NewVariable(session, initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grad, err := NewTensor(gradient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values [][]float64
+	for i := 0; i < steps; i++ {
+		if err := optimizer.Apply([]*Variable[float64]{variable}, []*Tensor[float64]{grad}); err != nil {
+			t.Fatal(err)
+		}
+
+		value, err := variable.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		values = append(values, value.value)
+	}
+
+	return values
+}
+
+func TestOptimizers(t *testing.T) {
+	sgd, err := NewSGD[float64](0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := applySteps(t, sgd, []float64{0.5, 1}, 1)
+	if !allClose(values[0], []float64{-0.05, -0.1}, 1e-12) {
+		t.Fatalf("sgd expected %v, got %v", []float64{-0.05, -0.1}, values[0])
+	}
+
+	// accumulator is 1 after first step and 1.9 after second step
+	momentum, err := NewSGD[float64](0.1, OptimizerMomentum(0.9))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values = applySteps(t, momentum, []float64{1}, 2)
+	if !allClose(values[0], []float64{-0.1}, 1e-12) {
+		t.Fatalf("momentum step 1 expected %v, got %v", []float64{-0.1}, values[0])
+	}
+	if !allClose(values[1], []float64{-0.29}, 1e-12) {
+		t.Fatalf("momentum step 2 expected %v, got %v", []float64{-0.29}, values[1])
+	}
+
+	// with bias correction, each step of a constant gradient moves
+	// variable by learning rate
+	adam, err := NewAdam[float64](0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values = applySteps(t, adam, []float64{2}, 2)
+	if !allClose(values[0], []float64{-0.1}, 1e-5) {
+		t.Fatalf("adam step 1 expected %v, got %v", []float64{-0.1}, values[0])
+	}
+	if !allClose(values[1], []float64{-0.2}, 1e-5) {
+		t.Fatalf("adam step 2 expected %v, got %v", []float64{-0.2}, values[1])
+	}
+
+	rmsProp, err := NewRMSProp[float64](0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values = applySteps(t, rmsProp, []float64{1}, 1)
+	if !allClose(values[0], []float64{-0.1 / math.Sqrt(0.1)}, 1e-5) {
+		t.Fatalf("rmsprop expected %v, got %v", []float64{-0.1 / math.Sqrt(0.1)}, values[0])
+	}
+
+	adagrad, err := NewAdagrad[float64](0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values = applySteps(t, adagrad, []float64{1}, 1)
+	if !allClose(values[0], []float64{-0.1 / math.Sqrt(1.1)}, 1e-12) {
+		t.Fatalf("adagrad expected %v, got %v", []float64{-0.1 / math.Sqrt(1.1)}, values[0])
+	}
+
+	if _, err := NewSGD[float64](0); err == nil {
+		t.Fatal("expected optimizer to fail for zero learning rate")
+	}
+
+	if _, err := NewAdam[float64](0.1, OptimizerBeta1(1)); err == nil {
+		t.Fatal("expected optimizer to fail for beta1 of 1")
+	}
+}
+
+func TestFit(t *testing.T) {
+	session, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err := session.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	zero, err := NewTensor([]float64{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewVariable(session, zero)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewVariable(session, zero)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fit y = 2x + 1
+	x, err := NewTensor([]float64{0, 1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]float64{1, 3, 5, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sgd, err := NewSGD[float64](0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	losses, err := Fit(meanSquaredErrorOp, sgd, []*Variable[float64]{w, b}, 500, x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(losses) != 500 {
+		t.Fatalf("expected 500 losses, got %d", len(losses))
+	}
+
+	// initial loss is mean of squared targets
+	if !allClose(losses[:1], []float64{21}, 1e-12) {
+		t.Fatalf("initial loss expected %v, got %v", []float64{21}, losses[:1])
+	}
+
+	if losses[len(losses)-1] > 1e-6 {
+		t.Fatalf("expected loss to converge, got %v", losses[len(losses)-1])
+	}
+
+	values, err := Snapshot(w, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(values[0].value, []float64{2}, 1e-3) {
+		t.Fatalf("weight expected %v, got %v", []float64{2}, values[0].value)
+	}
+	if !allClose(values[1].value, []float64{1}, 1e-3) {
+		t.Fatalf("bias expected %v, got %v", []float64{1}, values[1].value)
+	}
+}
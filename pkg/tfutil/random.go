@@ -0,0 +1,204 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// Seed is a pair of integers that drives tensorflow stateless random ops.
+// Output of random functions in this package is a deterministic function
+// of seed and shape, so same seed produces same values across runs and
+// machines
+type Seed [2]int64
+
+// RandomUniform creates a tensor of input shape with values drawn from
+// uniform distribution over half-open interval [minval, maxval)
+func RandomUniform[T FloatTypes](seed Seed, minval, maxval T, shape ...int) (*Tensor[T], error) {
+	if err := checkShape(shape); err != nil {
+		return nil, fmt.Errorf("invalid shape: %w", err)
+	}
+
+	if minval >= maxval {
+		return nil, fmt.Errorf("minval %v needs to be less than maxval %v", minval, maxval)
+	}
+
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	root := op.NewScope()
+	Output := op.StatelessRandomUniform(
+		root,
+		op.Const(root.SubScope("shape"), castToInt64(shape)),
+		op.Const(root.SubScope("seed"), seed[:]),
+		op.StatelessRandomUniformDtype(dataType),
+	)
+
+	// scale and shift unit interval to [minval, maxval)
+	return runOutput[T](root, nil, scaleAndShift(root, Output, maxval-minval, minval))
+}
+
+// RandomNormal creates a tensor of input shape with values drawn from
+// normal distribution with input mean and standard deviation
+func RandomNormal[T FloatTypes](seed Seed, mean, stddev T, shape ...int) (*Tensor[T], error) {
+	if err := checkShape(shape); err != nil {
+		return nil, fmt.Errorf("invalid shape: %w", err)
+	}
+
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	root := op.NewScope()
+	Output := op.StatelessRandomNormal(
+		root,
+		op.Const(root.SubScope("shape"), castToInt64(shape)),
+		op.Const(root.SubScope("seed"), seed[:]),
+		op.StatelessRandomNormalDtype(dataType),
+	)
+
+	return runOutput[T](root, nil, scaleAndShift(root, Output, stddev, mean))
+}
+
+// TruncatedNormal creates a tensor of input shape with values drawn from
+// normal distribution with input mean and standard deviation, except that
+// values more than two standard deviations away from mean are dropped and
+// re-picked
+func TruncatedNormal[T FloatTypes](seed Seed, mean, stddev T, shape ...int) (*Tensor[T], error) {
+	if err := checkShape(shape); err != nil {
+		return nil, fmt.Errorf("invalid shape: %w", err)
+	}
+
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	root := op.NewScope()
+	Output := op.StatelessTruncatedNormal(
+		root,
+		op.Const(root.SubScope("shape"), castToInt64(shape)),
+		op.Const(root.SubScope("seed"), seed[:]),
+		op.StatelessTruncatedNormalDtype(dataType),
+	)
+
+	return runOutput[T](root, nil, scaleAndShift(root, Output, stddev, mean))
+}
+
+// RandomInt creates a tensor of input shape with integer values drawn
+// from uniform distribution over half-open interval [minval, maxval)
+func RandomInt[T int32 | int64](seed Seed, minval, maxval T, shape ...int) (*Tensor[T], error) {
+	if err := checkShape(shape); err != nil {
+		return nil, fmt.Errorf("invalid shape: %w", err)
+	}
+
+	if minval >= maxval {
+		return nil, fmt.Errorf("minval %v needs to be less than maxval %v", minval, maxval)
+	}
+
+	root := op.NewScope()
+	Output := op.StatelessRandomUniformInt(
+		root,
+		op.Const(root.SubScope("shape"), castToInt64(shape)),
+		op.Const(root.SubScope("seed"), seed[:]),
+		op.Const(root.SubScope("minval"), minval),
+		op.Const(root.SubScope("maxval"), maxval),
+	)
+
+	return runOutput[T](root, nil, Output)
+}
+
+// Shuffle returns a new tensor with slices of input tensor randomly
+// permuted along axis. Negative axis counts from the last dimension
+func Shuffle[T PrimitiveTypes](input *Tensor[T], axis int, seed Seed) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	axis, err := normalizeAxis(axis, len(input.shape))
+	if err != nil {
+		return nil, fmt.Errorf("invalid axis: %w", err)
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := op.Placeholder(
+		root.SubScope("X"),
+		x.DataType(),
+		op.PlaceholderShape(
+			tf.MakeShape(castToInt64(input.shape)...),
+		),
+	)
+
+	// a random permutation is obtained by sorting random
+	// keys, one for each position along axis
+	n := input.shape[axis]
+	keys := op.StatelessRandomUniform(
+		root.SubScope("keys"),
+		op.Const(root.SubScope("shape"), []int64{int64(n)}),
+		op.Const(root.SubScope("seed"), seed[:]),
+	)
+	_, perm := op.TopKV2(root, keys, op.Const(root.SubScope("k"), int32(n)))
+
+	Output := op.GatherV2(root, X, perm, op.Const(root.SubScope("axis"), int64(axis)))
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// Categorical draws numSamples class indices for each row of logits,
+// which is a matrix of shape [batch, classes] holding unnormalized
+// log-probabilities. Output is of shape [batch, numSamples]
+func Categorical[T FloatTypes](logits *Tensor[T], numSamples int, seed Seed) (*Tensor[int64], error) {
+	if logits == nil {
+		return nil, fmt.Errorf("logits can't be nil")
+	}
+
+	if len(logits.shape) != 2 {
+		return nil, fmt.Errorf("logits need to be a matrix of shape [batch, classes], got shape %v", logits.shape)
+	}
+
+	if numSamples <= 0 {
+		return nil, fmt.Errorf("numSamples needs to be positive, got %d", numSamples)
+	}
+
+	x, err := logits.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := op.Placeholder(
+		root.SubScope("X"),
+		x.DataType(),
+		op.PlaceholderShape(
+			tf.MakeShape(castToInt64(logits.shape)...),
+		),
+	)
+
+	Output := op.StatelessMultinomial(
+		root,
+		X,
+		op.Const(root.SubScope("numSamples"), int32(numSamples)),
+		op.Const(root.SubScope("seed"), seed[:]),
+		op.StatelessMultinomialOutputDtype(tf.Int64),
+	)
+
+	return runOutput[int64](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// scaleAndShift computes x * scale + shift in the graph
+func scaleAndShift[T FloatTypes](scope *op.Scope, x tf.Output, scale, shift T) tf.Output {
+	return op.AddV2(
+		scope,
+		op.Mul(scope, x, op.Const(scope.SubScope("scale"), scale)),
+		op.Const(scope.SubScope("shift"), shift),
+	)
+}
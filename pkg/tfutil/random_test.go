@@ -0,0 +1,141 @@
+package tfutil
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestRandomUniformReproducible(t *testing.T) {
+	x, err := RandomUniform(Seed{1, 2}, -1.0, 1.0, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := RandomUniform(Seed{1, 2}, -1.0, 1.0, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := RandomUniform(Seed{2, 1}, -1.0, 1.0, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(x.value, y.value) {
+		t.Fatal("expected same values for same seed")
+	}
+
+	if equal(x.value, z.value) {
+		t.Fatal("expected different values for different seed")
+	}
+
+	if !equal(x.shape, []int{3, 4}) {
+		t.Fatal("output shape does not match expected shape")
+	}
+
+	for _, v := range x.value {
+		if v < -1 || v >= 1 {
+			t.Fatal("value out of range:", v)
+		}
+	}
+}
+
+func TestRandomNormal(t *testing.T) {
+	x, err := RandomNormal[float32](Seed{0, 0}, 10, 0.1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sum float32
+	for _, v := range x.value {
+		sum += v
+	}
+
+	if mean := sum / 100; mean < 9.9 || mean > 10.1 {
+		t.Fatal("sample mean too far from expected mean:", mean)
+	}
+}
+
+func TestTruncatedNormal(t *testing.T) {
+	x, err := TruncatedNormal(Seed{3, 4}, 0.0, 1.0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range x.value {
+		if v < -2 || v > 2 {
+			t.Fatal("value beyond two standard deviations:", v)
+		}
+	}
+}
+
+func TestRandomInt(t *testing.T) {
+	x, err := RandomInt[int64](Seed{5, 6}, 10, 20, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range x.value {
+		if v < 10 || v >= 20 {
+			t.Fatal("value out of range:", v)
+		}
+	}
+
+	if _, err := RandomInt[int32](Seed{5, 6}, 20, 10, 50); err == nil {
+		t.Fatal("expected random int to fail for empty interval")
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	x, err := NewTensorFromFunc(func(i int) int32 { return int32(i) }, 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := Shuffle(x, 0, Seed{7, 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(y.shape, x.shape) {
+		t.Fatal("output shape does not match input shape")
+	}
+
+	// rows are permuted as a whole
+	rows := make([]int, 0, 5)
+	for i := 0; i < 5; i++ {
+		if y.value[2*i+1] != y.value[2*i]+1 {
+			t.Fatal("row elements were not kept together")
+		}
+		rows = append(rows, int(y.value[2*i])/2)
+	}
+
+	sort.Ints(rows)
+	if !equal(rows, []int{0, 1, 2, 3, 4}) {
+		t.Fatal("output is not a permutation of input rows")
+	}
+
+	if _, err := Shuffle[int32](nil, 0, Seed{7, 8}); err == nil {
+		t.Fatal("expected shuffle to fail for nil input")
+	}
+}
+
+func TestCategorical(t *testing.T) {
+	logits, err := NewTensor([]float64{0, 100, 0, 100, 0, 0}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := Categorical(logits, 4, Seed{9, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(samples.value, []int64{1, 1, 1, 1, 0, 0, 0, 0}) || !equal(samples.shape, []int{2, 4}) {
+		t.Fatal("samples do not match expected values")
+	}
+
+	if _, err := Categorical[float64](nil, 4, Seed{9, 10}); err == nil {
+		t.Fatal("expected categorical to fail for nil logits")
+	}
+}
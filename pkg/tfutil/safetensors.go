@@ -0,0 +1,423 @@
+package tfutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/kubetrail/tfutil/pkg/proto/node"
+	tf "github.com/wamuir/graft/tensorflow"
+)
+
+// safetensors file consists of an 8 byte little endian header length, a
+// json header describing each tensor and a data buffer holding contents
+// of all tensors in little endian byte order
+const (
+	safeTensorsMetadataKey = "__metadata__"
+	safeTensorsMaxHeader   = 100 << 20
+)
+
+// safeTensorsDataTypes maps safetensors data types to tensorflow data
+// types and element sizes
+var safeTensorsDataTypes = map[string]struct {
+	dataType tf.DataType
+	size     int64
+}{
+	"BOOL": {tf.Bool, 1},
+	"U8":   {tf.Uint8, 1},
+	"I8":   {tf.Int8, 1},
+	"U16":  {tf.Uint16, 2},
+	"I16":  {tf.Int16, 2},
+	"F16":  {tf.Half, 2},
+	"BF16": {tf.Bfloat16, 2},
+	"U32":  {tf.Uint32, 4},
+	"I32":  {tf.Int32, 4},
+	"F32":  {tf.Float, 4},
+	"U64":  {tf.Uint64, 8},
+	"I64":  {tf.Int64, 8},
+	"F64":  {tf.Double, 8},
+}
+
+// SafeTensors is a safetensors file opened for lazy reads of individual
+// tensors
+type SafeTensors struct {
+	r        io.ReaderAt
+	offset   int64
+	tensors  map[string]*safeTensorInfo
+	metadata map[string]string
+}
+
+// safeTensorInfo is a header entry of a tensor
+type safeTensorInfo struct {
+	DataType    string   `json:"dtype"`
+	Shape       []int64  `json:"shape"`
+	DataOffsets [2]int64 `json:"data_offsets"`
+}
+
+// OpenSafeTensors parses and validates header of a safetensors file of
+// given size. Tensor contents are not read until requested via
+// LoadSafeTensor or ConstantNodes, so r needs to remain open until then
+func OpenSafeTensors(r io.ReaderAt, size int64) (*SafeTensors, error) {
+	prefix := make([]byte, 8)
+	if _, err := r.ReadAt(prefix, 0); err != nil {
+		return nil, fmt.Errorf("failed to read safetensors header length: %w", err)
+	}
+
+	headerSize := binary.LittleEndian.Uint64(prefix)
+	if headerSize > safeTensorsMaxHeader || int64(headerSize) > size-8 {
+		return nil, fmt.Errorf("invalid safetensors header length %d for file of %d bytes", headerSize, size)
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 8); err != nil {
+		return nil, fmt.Errorf("failed to read safetensors header: %w", err)
+	}
+
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(header, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse safetensors header: %w", err)
+	}
+
+	s := &SafeTensors{
+		r:        r,
+		offset:   8 + int64(headerSize),
+		tensors:  make(map[string]*safeTensorInfo, len(entries)),
+		metadata: make(map[string]string),
+	}
+
+	dataSize := size - s.offset
+	for name, entry := range entries {
+		if name == safeTensorsMetadataKey {
+			if err := json.Unmarshal(entry, &s.metadata); err != nil {
+				return nil, fmt.Errorf("failed to parse safetensors metadata: %w", err)
+			}
+			continue
+		}
+
+		info := &safeTensorInfo{}
+		if err := json.Unmarshal(entry, info); err != nil {
+			return nil, fmt.Errorf("failed to parse safetensors header of tensor %q: %w", name, err)
+		}
+
+		dataType, ok := safeTensorsDataTypes[info.DataType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported safetensors data type %q of tensor %q", info.DataType, name)
+		}
+
+		n := int64(1)
+		for _, dim := range info.Shape {
+			if dim < 0 || (dim > 0 && n > math.MaxInt64/dim) {
+				return nil, fmt.Errorf("invalid shape %v of tensor %q", info.Shape, name)
+			}
+			n *= dim
+		}
+
+		begin, end := info.DataOffsets[0], info.DataOffsets[1]
+		if begin < 0 || begin > end || end > dataSize {
+			return nil, fmt.Errorf("data offsets %v of tensor %q out of range [0, %d]", info.DataOffsets, name, dataSize)
+		}
+
+		if n > math.MaxInt64/dataType.size || end-begin != n*dataType.size {
+			return nil, fmt.Errorf("tensor %q of shape %v and data type %s needs %d bytes, got %d", name, info.Shape, info.DataType, n*dataType.size, end-begin)
+		}
+
+		s.tensors[name] = info
+	}
+
+	return s, nil
+}
+
+// Names returns sorted names of tensors
+func (s *SafeTensors) Names() []string {
+	names := make([]string, 0, len(s.tensors))
+	for name := range s.tensors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Metadata returns free form string metadata of the file
+func (s *SafeTensors) Metadata() map[string]string {
+	metadata := make(map[string]string, len(s.metadata))
+	for k, v := range s.metadata {
+		metadata[k] = v
+	}
+
+	return metadata
+}
+
+// DataType returns safetensors data type of named tensor, such as "F32"
+func (s *SafeTensors) DataType(name string) (string, error) {
+	info, ok := s.tensors[name]
+	if !ok {
+		return "", fmt.Errorf("tensor %q not found", name)
+	}
+
+	return info.DataType, nil
+}
+
+// Shape returns shape of named tensor, which is empty for scalars
+func (s *SafeTensors) Shape(name string) ([]int, error) {
+	info, ok := s.tensors[name]
+	if !ok {
+		return nil, fmt.Errorf("tensor %q not found", name)
+	}
+
+	shape := make([]int, len(info.Shape))
+	for i, dim := range info.Shape {
+		shape[i] = int(dim)
+	}
+
+	return shape, nil
+}
+
+// ConstantNodes reads named tensors, or all tensors if no names are
+// given, into const nodes of the same names, which can be added to a
+// graph via SetNodes of graph.Def. F16 and BF16 tensors are not supported
+func (s *SafeTensors) ConstantNodes(names ...string) ([]*node.Def, error) {
+	if len(names) == 0 {
+		names = s.Names()
+	}
+
+	nodes := make([]*node.Def, len(names))
+	for i, name := range names {
+		info, ok := s.tensors[name]
+		if !ok {
+			return nil, fmt.Errorf("tensor %q not found", name)
+		}
+
+		dataType := safeTensorsDataTypes[info.DataType].dataType
+		if dataType == tf.Half || dataType == tf.Bfloat16 {
+			return nil, fmt.Errorf("data type %s of tensor %q is not supported for const nodes", info.DataType, name)
+		}
+
+		tfTensor, err := tf.ReadTensor(dataType, clone(info.Shape), s.section(info))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tensor %q: %w", name, err)
+		}
+
+		if nodes[i], err = node.NewConstantNode(name, tfTensor); err != nil {
+			return nil, fmt.Errorf("failed to create const node for tensor %q: %w", name, err)
+		}
+	}
+
+	return nodes, nil
+}
+
+// section returns reader over contents of a tensor
+func (s *SafeTensors) section(info *safeTensorInfo) *io.SectionReader {
+	return io.NewSectionReader(s.r, s.offset+info.DataOffsets[0], info.DataOffsets[1]-info.DataOffsets[0])
+}
+
+// LoadSafeTensor reads named tensor of s, reading only its own contents.
+// Safetensors data type needs to match T, except that F16 and BF16
+// tensors are also read as float32. A scalar is read as a vector of
+// length 1
+func LoadSafeTensor[T PrimitiveTypes](s *SafeTensors, name string) (*Tensor[T], error) {
+	info, ok := s.tensors[name]
+	if !ok {
+		return nil, fmt.Errorf("tensor %q not found", name)
+	}
+
+	shape, err := s.Shape(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shape) == 0 {
+		shape = []int{1}
+	}
+
+	n, err := numElements(shape)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shape %v of tensor %q: %w", shape, name, err)
+	}
+
+	values := make([]T, n)
+	r := s.section(info)
+
+	switch p := any(values).(type) {
+	case []float32:
+		if info.DataType == "F16" || info.DataType == "BF16" {
+			halves := make([]uint16, n)
+			if err := binary.Read(r, binary.LittleEndian, halves); err != nil {
+				return nil, fmt.Errorf("failed to read tensor %q: %w", name, err)
+			}
+
+			for i, h := range halves {
+				if info.DataType == "BF16" {
+					p[i] = math.Float32frombits(uint32(h) << 16)
+				} else {
+					p[i] = halfToFloat32(h)
+				}
+			}
+
+			return NewTensor(values, shape...)
+		}
+	}
+
+	dataType, err := safeTensorsDataType[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.DataType != dataType {
+		return nil, fmt.Errorf("tensor %q has data type %s, which does not match data type %T", name, info.DataType, *new(T))
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, values); err != nil {
+		return nil, fmt.Errorf("failed to read tensor %q: %w", name, err)
+	}
+
+	return NewTensor(values, shape...)
+}
+
+// LoadSafeTensors reads all tensors of a safetensors file of given size,
+// which need to be of data type T. See LoadSafeTensor for details
+func LoadSafeTensors[T PrimitiveTypes](r io.ReaderAt, size int64) (map[string]*Tensor[T], error) {
+	s, err := OpenSafeTensors(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	tensors := make(map[string]*Tensor[T], len(s.tensors))
+	for _, name := range s.Names() {
+		if tensors[name], err = LoadSafeTensor[T](s, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return tensors, nil
+}
+
+// SaveSafeTensors writes named tensors along with optional metadata to w
+// in safetensors format. Contents are laid out in order of names.
+// String and complex data types are not supported by the format
+func SaveSafeTensors[T PrimitiveTypes](w io.Writer, tensors map[string]*Tensor[T], metadata map[string]string) error {
+	dataType, err := safeTensorsDataType[T]()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(tensors))
+	for name, tensor := range tensors {
+		if name == safeTensorsMetadataKey {
+			return fmt.Errorf("tensor name %q is reserved", name)
+		}
+
+		if tensor == nil {
+			return fmt.Errorf("tensor %q can't be nil", name)
+		}
+
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make(map[string]any, len(tensors)+1)
+	if len(metadata) > 0 {
+		entries[safeTensorsMetadataKey] = metadata
+	}
+
+	data := &bytes.Buffer{}
+	for _, name := range names {
+		tensor := tensors[name]
+		begin := int64(data.Len())
+		if err := binary.Write(data, binary.LittleEndian, tensor.value); err != nil {
+			return fmt.Errorf("failed to serialize tensor %q: %w", name, err)
+		}
+
+		shape := make([]int64, len(tensor.shape))
+		for i, dim := range tensor.shape {
+			shape[i] = int64(dim)
+		}
+
+		entries[name] = &safeTensorInfo{
+			DataType:    dataType,
+			Shape:       shape,
+			DataOffsets: [2]int64{begin, int64(data.Len())},
+		}
+	}
+
+	header, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to serialize safetensors header: %w", err)
+	}
+
+	// header is padded with spaces so that data buffer is 8 byte aligned
+	if pad := len(header) % 8; pad != 0 {
+		header = append(header, bytes.Repeat([]byte{' '}, 8-pad)...)
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(header))); err != nil {
+		return fmt.Errorf("failed to write safetensors header length: %w", err)
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write safetensors header: %w", err)
+	}
+
+	if _, err := data.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write safetensors data: %w", err)
+	}
+
+	return nil
+}
+
+// safeTensorsDataType returns safetensors data type corresponding to
+// go data type T
+func safeTensorsDataType[T PrimitiveTypes]() (string, error) {
+	switch any(*new(T)).(type) {
+	case bool:
+		return "BOOL", nil
+	case uint8:
+		return "U8", nil
+	case int8:
+		return "I8", nil
+	case uint16:
+		return "U16", nil
+	case int16:
+		return "I16", nil
+	case uint32:
+		return "U32", nil
+	case int32:
+		return "I32", nil
+	case float32:
+		return "F32", nil
+	case uint64:
+		return "U64", nil
+	case int64:
+		return "I64", nil
+	case float64:
+		return "F64", nil
+	}
+
+	return "", fmt.Errorf("data type %T is not supported by safetensors", *new(T))
+}
+
+// halfToFloat32 converts an IEEE 754 half precision value to float32
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exponent := uint32(h>>10) & 0x1f
+	mantissa := uint32(h) & 0x3ff
+
+	switch exponent {
+	case 0x1f:
+		// infinity or NaN
+		return math.Float32frombits(sign | 0xff<<23 | mantissa<<13)
+	case 0:
+		// zero or subnormal, whose value is mantissa * 2^-24
+		f := float32(mantissa) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	}
+
+	return math.Float32frombits(sign | (exponent+112)<<23 | mantissa<<13)
+}
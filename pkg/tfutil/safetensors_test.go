@@ -0,0 +1,170 @@
+package tfutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/kubetrail/tfutil/pkg/proto/graph"
+	tf "github.com/wamuir/graft/tensorflow"
+)
+
+// countingReaderAt counts bytes read via ReadAt
+type countingReaderAt struct {
+	r io.ReaderAt
+	n int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.n += n
+	return n, err
+}
+
+func TestSafeTensorsRoundTrip(t *testing.T) {
+	weight, err := NewTensor([]float32{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bias, err := NewTensor([]float32{7, 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := SaveSafeTensors(
+		buf,
+		map[string]*Tensor[float32]{"dense.weight": weight, "dense.bias": bias},
+		map[string]string{"format": "pt"},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	headerSize := binary.LittleEndian.Uint64(buf.Bytes())
+	if (8+headerSize)%8 != 0 {
+		t.Fatalf("expected 8 byte aligned data buffer, got header of %d bytes", headerSize)
+	}
+
+	r := &countingReaderAt{r: bytes.NewReader(buf.Bytes())}
+	s, err := OpenSafeTensors(r, int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(s.Names(), []string{"dense.bias", "dense.weight"}) || s.Metadata()["format"] != "pt" {
+		t.Fatalf("unexpected names %v and metadata %v", s.Names(), s.Metadata())
+	}
+
+	if dataType, err := s.DataType("dense.weight"); err != nil || dataType != "F32" {
+		t.Fatalf("expected data type F32, got %q: %v", dataType, err)
+	}
+
+	// only contents of requested tensor are read
+	read := r.n
+	output, err := LoadSafeTensor[float32](s, "dense.weight")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.n-read != 24 {
+		t.Fatalf("expected 24 bytes read, got %d", r.n-read)
+	}
+
+	if !equal(output.value, weight.value) || !equal(output.shape, weight.shape) {
+		t.Fatal("safetensors round trip does not match input")
+	}
+
+	if _, err := LoadSafeTensor[float64](s, "dense.bias"); err == nil {
+		t.Fatal("expected error for mismatched data type")
+	}
+
+	tensors, err := LoadSafeTensors[float32](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tensors) != 2 || !equal(tensors["dense.bias"].value, bias.value) {
+		t.Fatal("unexpected tensors loaded")
+	}
+
+	// loaded weights are injected into a graph as const nodes
+	nodes, err := s.ConstantNodes("dense.bias")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graphDef, err := graph.NewGraphDef()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graphDef.SetNodes(nodes...)
+
+	g, err := graphDef.Export("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess, err := tf.NewSession(g, &tf.SessionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(sess *tf.Session) {
+		if err := sess.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}(sess)
+
+	out, err := sess.Run(nil, []tf.Output{g.Operation("dense.bias").Output(0)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, ok := out[0].Value().([]float32); !ok || !equal(values, bias.value) {
+		t.Fatalf("unexpected const node value %v", out[0].Value())
+	}
+}
+
+func TestSafeTensorsHalf(t *testing.T) {
+	header := []byte(`{"h":{"dtype":"F16","shape":[4],"data_offsets":[0,8]},"b":{"dtype":"BF16","shape":[],"data_offsets":[8,10]}}`)
+
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(header)))
+	buf.Write(header)
+	_ = binary.Write(buf, binary.LittleEndian, []uint16{0x3c00, 0xc000, 0x0001, 0x7c00, 0x3fc0})
+
+	s, err := OpenSafeTensors(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := LoadSafeTensor[float32](s, "h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h.value[0] != 1 || h.value[1] != -2 || h.value[2] != 1.0/(1<<24) || !math.IsInf(float64(h.value[3]), 1) {
+		t.Fatalf("unexpected half values %v", h.value)
+	}
+
+	b, err := LoadSafeTensor[float32](s, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(b.shape, []int{1}) || b.value[0] != 1.5 {
+		t.Fatalf("unexpected bfloat16 value %v of shape %v", b.value, b.shape)
+	}
+
+	if _, err := s.ConstantNodes("h"); err == nil {
+		t.Fatal("expected error for const node of half tensor")
+	}
+
+	if _, err := OpenSafeTensors(bytes.NewReader(buf.Bytes()), int64(buf.Len()-1)); err == nil {
+		t.Fatal("expected error for truncated data")
+	}
+}
@@ -0,0 +1,237 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// Aggregation defines how values sharing a key are combined by GroupBy
+type Aggregation int
+
+const (
+	// AggregateSum sums values
+	AggregateSum Aggregation = iota
+	// AggregateMean averages values
+	AggregateMean
+	// AggregateMax picks maximum value
+	AggregateMax
+	// AggregateMin picks minimum value
+	AggregateMin
+)
+
+// SegmentSum sums slices of data along its first dimension sharing the
+// same segment id. ids is a vector of length equal to the first dimension
+// of data holding non-negative ids sorted in non-decreasing order. Output
+// has shape [max(ids) + 1, ...] with zeros for ids that do not occur
+func SegmentSum[T NumericTypes, I int32 | int64](data *Tensor[T], ids *Tensor[I]) (*Tensor[T], error) {
+	return segment(data, ids, -1, op.SegmentSum)
+}
+
+// SegmentMean averages slices of data along its first dimension sharing
+// the same segment id. Output for ids that do not occur is zero. See
+// SegmentSum for details
+func SegmentMean[T NumericTypes, I int32 | int64](data *Tensor[T], ids *Tensor[I]) (*Tensor[T], error) {
+	return segment(data, ids, -1, op.SegmentMean)
+}
+
+// SegmentMax computes maximum of slices of data along its first dimension
+// sharing the same segment id. Unlike UnsortedSegmentMax, output for ids
+// that do not occur is zero rather than the lowest value of the data
+// type. See SegmentSum for details
+func SegmentMax[T RealTypes, I int32 | int64](data *Tensor[T], ids *Tensor[I]) (*Tensor[T], error) {
+	return segment(data, ids, -1, op.SegmentMax)
+}
+
+// SegmentMin computes minimum of slices of data along its first dimension
+// sharing the same segment id. Unlike UnsortedSegmentMin, output for ids
+// that do not occur is zero rather than the largest value of the data
+// type. See SegmentSum for details
+func SegmentMin[T RealTypes, I int32 | int64](data *Tensor[T], ids *Tensor[I]) (*Tensor[T], error) {
+	return segment(data, ids, -1, op.SegmentMin)
+}
+
+// UnsortedSegmentSum sums slices of data along its first dimension sharing
+// the same segment id. ids is a vector of length equal to the first
+// dimension of data holding ids in any order, which need to be less than
+// numSegments. Slices with negative ids are dropped. Output has shape
+// [numSegments, ...] with zeros for ids that do not occur
+func UnsortedSegmentSum[T NumericTypes, I int32 | int64](data *Tensor[T], ids *Tensor[I], numSegments int) (*Tensor[T], error) {
+	return segment(data, ids, numSegments, unsortedSegment(op.UnsortedSegmentSum, numSegments))
+}
+
+// UnsortedSegmentMean averages slices of data along its first dimension
+// sharing the same segment id. See UnsortedSegmentSum for details
+func UnsortedSegmentMean[T NumericTypes, I int32 | int64](data *Tensor[T], ids *Tensor[I], numSegments int) (*Tensor[T], error) {
+	if data == nil {
+		return nil, fmt.Errorf("data can't be nil")
+	}
+
+	rank := len(data.shape)
+	return segment(data, ids, numSegments, func(scope *op.Scope, x, segmentIds tf.Output) tf.Output {
+		n := op.Const(scope.SubScope("numSegments"), int64(numSegments))
+		sum := op.UnsortedSegmentSum(scope, x, segmentIds, n)
+
+		// count slices per segment as int64, since Maximum has no
+		// complex kernel, clamped to 1 for empty segments and cast
+		// to data type of x only for division
+		ones := op.OnesLike(scope, op.Cast(scope, segmentIds, tf.Int64))
+		count := op.UnsortedSegmentSum(scope.SubScope("count"), ones, segmentIds, n)
+		count = op.Maximum(scope, count, op.OnesLike(scope.SubScope("min"), count))
+		count = op.Cast(scope.SubScope("count"), count, x.DataType())
+
+		shape := make([]int64, rank)
+		for i := range shape {
+			shape[i] = 1
+		}
+		shape[0] = int64(numSegments)
+
+		return op.Div(scope, sum, op.Reshape(scope, count, op.Const(scope.SubScope("shape"), shape)))
+	})
+}
+
+// UnsortedSegmentMax computes maximum of slices of data along its first
+// dimension sharing the same segment id. Output for ids that do not occur
+// is the lowest value of the data type. See UnsortedSegmentSum for details
+func UnsortedSegmentMax[T RealTypes, I int32 | int64](data *Tensor[T], ids *Tensor[I], numSegments int) (*Tensor[T], error) {
+	return segment(data, ids, numSegments, unsortedSegment(op.UnsortedSegmentMax, numSegments))
+}
+
+// UnsortedSegmentMin computes minimum of slices of data along its first
+// dimension sharing the same segment id. Output for ids that do not occur
+// is the largest value of the data type. See UnsortedSegmentSum for details
+func UnsortedSegmentMin[T RealTypes, I int32 | int64](data *Tensor[T], ids *Tensor[I], numSegments int) (*Tensor[T], error) {
+	return segment(data, ids, numSegments, unsortedSegment(op.UnsortedSegmentMin, numSegments))
+}
+
+// GroupBy aggregates slices of values along its first dimension sharing
+// the same key, where keys is a vector of length equal to the first
+// dimension of values. Output unique keys are in the order of their first
+// occurrence and aggregates has shape [len(unique), ...] with i-th slice
+// being the aggregate of values for i-th unique key
+func GroupBy[K PrimitiveTypes, T NumericTypes](keys *Tensor[K], values *Tensor[T], aggregation Aggregation) (unique *Tensor[K], aggregates *Tensor[T], err error) {
+	if keys == nil || values == nil {
+		return nil, nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(keys.shape) != 1 || keys.shape[0] != values.shape[0] {
+		return nil, nil, fmt.Errorf(
+			"keys need to be a vector of length equal to first dimension of values, got shapes %v and %v",
+			keys.shape, values.shape,
+		)
+	}
+
+	index := make(map[K]int64)
+	var uniqueKeys []K
+	ids := make([]int64, len(keys.value))
+	for i, key := range keys.value {
+		id, ok := index[key]
+		if !ok {
+			id = int64(len(uniqueKeys))
+			index[key] = id
+			uniqueKeys = append(uniqueKeys, key)
+		}
+		ids[i] = id
+	}
+
+	segmentIds, err := NewTensor(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if unique, err = NewTensor(uniqueKeys); err != nil {
+		return nil, nil, err
+	}
+
+	numSegments := len(uniqueKeys)
+	switch aggregation {
+	case AggregateSum:
+		aggregates, err = UnsortedSegmentSum(values, segmentIds, numSegments)
+	case AggregateMean:
+		aggregates, err = UnsortedSegmentMean(values, segmentIds, numSegments)
+	case AggregateMax, AggregateMin:
+		switch any(*new(T)).(type) {
+		case complex64, complex128:
+			return nil, nil, fmt.Errorf("aggregation %d is not supported for complex values", aggregation)
+		}
+
+		f := op.UnsortedSegmentMax
+		if aggregation == AggregateMin {
+			f = op.UnsortedSegmentMin
+		}
+
+		aggregates, err = segment(values, segmentIds, numSegments, unsortedSegment(f, numSegments))
+	default:
+		return nil, nil, fmt.Errorf("invalid aggregation %d", aggregation)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return unique, aggregates, nil
+}
+
+// unsortedSegment binds numSegments to an unsorted segment op
+func unsortedSegment(
+	f func(scope *op.Scope, data, segmentIds, numSegments tf.Output) tf.Output,
+	numSegments int,
+) func(scope *op.Scope, data, segmentIds tf.Output) tf.Output {
+	return func(scope *op.Scope, data, segmentIds tf.Output) tf.Output {
+		return f(scope, data, segmentIds, op.Const(scope.SubScope("numSegments"), int64(numSegments)))
+	}
+}
+
+// segment validates ids against data and applies segment op f. ids
+// need to be sorted if numSegments is negative and less than numSegments
+// otherwise
+func segment[T PrimitiveTypes, I int32 | int64](
+	data *Tensor[T],
+	ids *Tensor[I],
+	numSegments int,
+	f func(scope *op.Scope, data, segmentIds tf.Output) tf.Output,
+) (*Tensor[T], error) {
+	if data == nil || ids == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(ids.shape) != 1 || ids.shape[0] != data.shape[0] {
+		return nil, fmt.Errorf(
+			"ids need to be a vector of length equal to first dimension of data, got shapes %v and %v",
+			ids.shape, data.shape,
+		)
+	}
+
+	sorted := numSegments < 0
+	if !sorted && numSegments == 0 {
+		return nil, fmt.Errorf("numSegments needs to be positive")
+	}
+
+	for i, id := range ids.value {
+		switch {
+		case sorted && id < 0:
+			return nil, fmt.Errorf("id %d at index %d can't be negative", id, i)
+		case sorted && i > 0 && id < ids.value[i-1]:
+			return nil, fmt.Errorf("ids need to be sorted, got id %d at index %d after id %d", id, i, ids.value[i-1])
+		case !sorted && int64(id) >= int64(numSegments):
+			return nil, fmt.Errorf("id %d at index %d needs to be less than numSegments %d", id, i, numSegments)
+		}
+	}
+
+	x, err := data.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	y, err := ids.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	Y := placeholder(root, "Y", y)
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: x, Y: y}, f(root, X, Y))
+}
@@ -0,0 +1,180 @@
+package tfutil
+
+import (
+	"testing"
+)
+
+func TestSegment(t *testing.T) {
+	data, err := NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := NewTensor([]int32{0, 0, 2, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := SegmentSum(data, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(sum.value, []float64{4, 6, 0, 0, 12, 14}) || !equal(sum.shape, []int{3, 2}) {
+		t.Fatal("segment sum does not match expected value")
+	}
+
+	mean, err := SegmentMean(data, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(mean.value, []float64{2, 3, 0, 0, 6, 7}) {
+		t.Fatal("segment mean does not match expected value")
+	}
+
+	maxima, err := SegmentMax(data, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(maxima.value, []float64{3, 4, 0, 0, 7, 8}) {
+		t.Fatal("segment max does not match expected value")
+	}
+
+	minima, err := SegmentMin(data, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(minima.value, []float64{1, 2, 0, 0, 5, 6}) {
+		t.Fatal("segment min does not match expected value")
+	}
+
+	unsorted, err := NewTensor([]int32{2, 0, 2, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SegmentSum(data, unsorted); err == nil {
+		t.Fatal("expected segment sum to fail for unsorted ids")
+	}
+}
+
+func TestUnsortedSegment(t *testing.T) {
+	data, err := NewTensor([]int64{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := NewTensor([]int64{1, 0, 1, -1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := UnsortedSegmentSum(data, ids, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(sum.value, []int64{7, 4, 0}) || !equal(sum.shape, []int{3}) {
+		t.Fatal("unsorted segment sum does not match expected value")
+	}
+
+	mean, err := UnsortedSegmentMean(data, ids, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(mean.value, []int64{3, 2, 0}) {
+		t.Fatal("unsorted segment mean does not match expected value")
+	}
+
+	maxima, err := UnsortedSegmentMax(data, ids, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(maxima.value, []int64{5, 3}) {
+		t.Fatal("unsorted segment max does not match expected value")
+	}
+
+	minima, err := UnsortedSegmentMin(data, ids, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(minima.value, []int64{2, 1}) {
+		t.Fatal("unsorted segment min does not match expected value")
+	}
+
+	c, err := NewTensor([]complex128{1 + 1i, 2, 3 + 3i, 4, 5 - 1i})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	complexMean, err := UnsortedSegmentMean(c, ids, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(complexMean.value, []complex128{3.5 - 0.5i, 2 + 2i, 0}) {
+		t.Fatal("unsorted segment mean of complex values does not match expected value:", complexMean.value)
+	}
+
+	if _, err := UnsortedSegmentSum(data, ids, 1); err == nil {
+		t.Fatal("expected unsorted segment sum to fail for id out of range")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	keys, err := NewTensor([]string{"bob", "alice", "bob", "carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := NewTensor([]float32{10, 1, 30, 5, 2, 4, 7, 7}, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unique, sums, err := GroupBy(keys, values, AggregateSum)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(unique.value, []string{"bob", "alice", "carol"}) {
+		t.Fatal("group by keys do not match expected value")
+	}
+
+	if !equal(sums.value, []float32{12, 5, 30, 5, 7, 7}) || !equal(sums.shape, []int{3, 2}) {
+		t.Fatal("group by sums do not match expected value")
+	}
+
+	_, means, err := GroupBy(keys, values, AggregateMean)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(means.value, []float32{6, 2.5, 30, 5, 7, 7}) {
+		t.Fatal("group by means do not match expected value")
+	}
+
+	_, maxima, err := GroupBy(keys, values, AggregateMax)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(maxima.value, []float32{10, 4, 30, 5, 7, 7}) {
+		t.Fatal("group by maxima do not match expected value")
+	}
+
+	c, err := NewTensor([]complex64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := GroupBy(keys, c, AggregateMin); err == nil {
+		t.Fatal("expected group by to fail for min of complex values")
+	}
+}
@@ -0,0 +1,480 @@
+package tfutil
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// Interpolation defines how quantiles falling between two data
+// points are computed
+type Interpolation int
+
+const (
+	// InterpolationLinear interpolates linearly between data points
+	InterpolationLinear Interpolation = iota
+	// InterpolationLower picks the lower data point
+	InterpolationLower
+	// InterpolationHigher picks the higher data point
+	InterpolationHigher
+	// InterpolationNearest picks the nearest data point with ties
+	// resolved to the even index
+	InterpolationNearest
+	// InterpolationMidpoint averages the two data points
+	InterpolationMidpoint
+)
+
+// StatsOption configures statistics computed over tensors
+type StatsOption func(*statsOptions)
+
+type statsOptions struct {
+	axes          []int
+	keepDims      bool
+	ddof          int
+	interpolation Interpolation
+}
+
+// StatsAxes sets axes to reduce. Negative axes count from the last
+// dimension. Default is all axes
+func StatsAxes(axes ...int) StatsOption {
+	return func(o *statsOptions) {
+		o.axes = axes
+	}
+}
+
+// StatsKeepDims sets retention of reduced axes with length 1
+func StatsKeepDims(value bool) StatsOption {
+	return func(o *statsOptions) {
+		o.keepDims = value
+	}
+}
+
+// StatsDDOF sets delta degrees of freedom, i.e., variance is computed
+// by dividing by n - ddof, where n is the number of reduced elements.
+// Default is 0 for Moments and Std and 1 for Covariance
+func StatsDDOF(ddof int) StatsOption {
+	return func(o *statsOptions) {
+		o.ddof = ddof
+	}
+}
+
+// StatsInterpolation sets interpolation mode for quantiles.
+// Default is InterpolationLinear
+func StatsInterpolation(interpolation Interpolation) StatsOption {
+	return func(o *statsOptions) {
+		o.interpolation = interpolation
+	}
+}
+
+func newStatsOptions(options []StatsOption, ddof int) *statsOptions {
+	o := &statsOptions{ddof: ddof}
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// reduction returns normalized reduction axes for given shape along with
+// number of reduced elements
+func (o *statsOptions) reduction(shape []int) ([]int, int, error) {
+	axes := o.axes
+	if len(axes) == 0 {
+		axes = make([]int, len(shape))
+		for i := range axes {
+			axes[i] = i
+		}
+	}
+
+	axes, _, err := splitAxes(axes, len(shape))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid axes: %w", err)
+	}
+
+	n := 1
+	for _, axis := range axes {
+		n *= shape[axis]
+	}
+
+	return axes, n, nil
+}
+
+// Moments computes mean and variance of input along axes set via
+// StatsAxes, which defaults to all axes. Variance is normalized by
+// n - ddof, where ddof is set via StatsDDOF and defaults to 0
+func Moments[T FloatTypes](input *Tensor[T], options ...StatsOption) (mean, variance *Tensor[T], err error) {
+	if input == nil {
+		return nil, nil, fmt.Errorf("input can't be nil")
+	}
+
+	o := newStatsOptions(options, 0)
+	axes, n, err := o.reduction(input.shape)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if n-o.ddof <= 0 {
+		return nil, nil, fmt.Errorf("ddof %d needs to be less than number of reduced elements %d", o.ddof, n)
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	reductionAxes := op.Const(root.SubScope("axes"), castToInt64(axes))
+
+	Mean := op.Mean(root, X, reductionAxes, op.MeanKeepDims(true))
+	Variance := op.Mean(
+		root,
+		op.SquaredDifference(root, X, Mean),
+		reductionAxes,
+		op.MeanKeepDims(true),
+	)
+
+	if o.ddof != 0 {
+		correction := T(float64(n) / float64(n-o.ddof))
+		Variance = op.Mul(root, Variance, op.Const(root.SubScope("correction"), correction))
+	}
+
+	if !o.keepDims {
+		Mean = op.Squeeze(root, Mean, op.SqueezeAxis(castToInt64(axes)))
+		Variance = op.Squeeze(root, Variance, op.SqueezeAxis(castToInt64(axes)))
+	}
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, Mean, Variance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mean, err = fromTfTensor[T](out[0]); err != nil {
+		return nil, nil, err
+	}
+
+	if variance, err = fromTfTensor[T](out[1]); err != nil {
+		return nil, nil, err
+	}
+
+	return mean, variance, nil
+}
+
+// MomentsScalar computes mean and variance over all elements of input.
+// Reduction axes set via options are ignored
+func MomentsScalar[T FloatTypes](input *Tensor[T], options ...StatsOption) (mean, variance *Scalar[T], err error) {
+	m, v, err := Moments(input, append(slices.Clone(options), StatsAxes(), StatsKeepDims(false))...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewScalar(m.value[0]), NewScalar(v.value[0]), nil
+}
+
+// Std computes standard deviation of input along axes set via
+// StatsAxes, which defaults to all axes. See Moments for details
+func Std[T FloatTypes](input *Tensor[T], options ...StatsOption) (*Tensor[T], error) {
+	_, variance, err := Moments(input, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	variance.ApplyFunc(func(v T) T { return T(math.Sqrt(float64(v))) })
+	return variance, nil
+}
+
+// StdScalar computes standard deviation over all elements of input.
+// Reduction axes set via options are ignored
+func StdScalar[T FloatTypes](input *Tensor[T], options ...StatsOption) (*Scalar[T], error) {
+	_, variance, err := MomentsScalar(input, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScalar(T(math.Sqrt(float64(variance.value)))), nil
+}
+
+// Quantile computes quantiles qs, each within [0, 1], of input along
+// axes set via StatsAxes, which defaults to all axes. Output has shape
+// [len(qs), ...], where trailing dimensions are the non-reduced ones, or
+// all dimensions with reduced ones set to 1 when StatsKeepDims is set.
+// Quantiles falling between data points are computed as per interpolation
+// mode set via StatsInterpolation. Quantiles over slices containing NaN
+// values are NaN
+func Quantile[T FloatTypes](input *Tensor[T], qs []float64, options ...StatsOption) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(qs) == 0 {
+		return nil, fmt.Errorf("at least one quantile is needed")
+	}
+
+	for _, q := range qs {
+		if !(q >= 0 && q <= 1) {
+			return nil, fmt.Errorf("quantiles need to be within [0, 1], got %v", qs)
+		}
+	}
+
+	o := newStatsOptions(options, 0)
+	if o.interpolation < InterpolationLinear || o.interpolation > InterpolationMidpoint {
+		return nil, fmt.Errorf("invalid interpolation mode %d", o.interpolation)
+	}
+
+	axes, n, err := o.reduction(input.shape)
+	if err != nil {
+		return nil, err
+	}
+
+	reduced := make([]bool, len(input.shape))
+	for _, axis := range axes {
+		reduced[axis] = true
+	}
+
+	// move reduced axes innermost, so that each slice to compute
+	// quantiles over is contiguous
+	var perm []int
+	shape := []int{len(qs)}
+	for axis, dim := range input.shape {
+		switch {
+		case !reduced[axis]:
+			perm = append(perm, axis)
+			shape = append(shape, dim)
+		case o.keepDims:
+			shape = append(shape, 1)
+		}
+	}
+	perm = append(perm, axes...)
+
+	values := transposeValues(input.value, input.shape, perm)
+	numSlices := len(values) / n
+
+	output := make([]T, len(qs)*numSlices)
+	slice := make([]float64, n)
+	for s := 0; s < numSlices; s++ {
+		hasNaN := false
+		for i, v := range values[s*n : (s+1)*n] {
+			slice[i] = float64(v)
+			hasNaN = hasNaN || math.IsNaN(slice[i])
+		}
+		sort.Float64s(slice)
+
+		for k, q := range qs {
+			output[k*numSlices+s] = T(math.NaN())
+			if !hasNaN {
+				output[k*numSlices+s] = T(quantileOf(slice, q, o.interpolation))
+			}
+		}
+	}
+
+	return NewTensor(output, shape...)
+}
+
+// QuantileScalar computes quantile q of all elements of input.
+// Reduction axes set via options are ignored
+func QuantileScalar[T FloatTypes](input *Tensor[T], q float64, options ...StatsOption) (*Scalar[T], error) {
+	output, err := Quantile(input, []float64{q}, append(slices.Clone(options), StatsAxes(), StatsKeepDims(false))...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScalar(output.value[0]), nil
+}
+
+// Percentile computes percentiles ps, each within [0, 100], of input.
+// See Quantile for details
+func Percentile[T FloatTypes](input *Tensor[T], ps []float64, options ...StatsOption) (*Tensor[T], error) {
+	qs := make([]float64, len(ps))
+	for i, p := range ps {
+		qs[i] = p / 100
+	}
+
+	return Quantile(input, qs, options...)
+}
+
+// PercentileScalar computes percentile p of all elements of input.
+// Reduction axes set via options are ignored
+func PercentileScalar[T FloatTypes](input *Tensor[T], p float64, options ...StatsOption) (*Scalar[T], error) {
+	return QuantileScalar(input, p/100, options...)
+}
+
+// quantileOf computes quantile q of sorted values
+func quantileOf(sorted []float64, q float64, interpolation Interpolation) float64 {
+	h := q * float64(len(sorted)-1)
+	lo, hi := int(math.Floor(h)), int(math.Ceil(h))
+
+	switch interpolation {
+	case InterpolationLower:
+		return sorted[lo]
+	case InterpolationHigher:
+		return sorted[hi]
+	case InterpolationNearest:
+		return sorted[int(math.RoundToEven(h))]
+	case InterpolationMidpoint:
+		return (sorted[lo] + sorted[hi]) / 2
+	default:
+		return sorted[lo] + (h-float64(lo))*(sorted[hi]-sorted[lo])
+	}
+}
+
+// HistogramFixedWidth counts values of input falling into nbins equal
+// width bins spanning [minval, maxval]. Values below minval are counted
+// in the first bin and values above maxval in the last bin. Output is a
+// vector of length nbins
+func HistogramFixedWidth[T int32 | int64 | float32 | float64](input *Tensor[T], minval, maxval T, nbins int) (*Tensor[int64], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if minval >= maxval {
+		return nil, fmt.Errorf("minval %v needs to be less than maxval %v", minval, maxval)
+	}
+
+	if nbins <= 0 {
+		return nil, fmt.Errorf("nbins needs to be positive, got %d", nbins)
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	Output := op.HistogramFixedWidth(
+		root,
+		X,
+		op.Const(root.SubScope("range"), []T{minval, maxval}),
+		op.Const(root.SubScope("nbins"), int32(nbins)),
+		op.HistogramFixedWidthDtype(tf.Int64),
+	)
+
+	return runOutput[int64](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// Covariance computes covariance matrix of input of shape [samples,
+// features], resulting in shape [features, features]. Covariance is
+// normalized by samples - ddof, where ddof is set via StatsDDOF and
+// defaults to 1. Other options do not apply and are rejected
+func Covariance[T FloatTypes](input *Tensor[T], options ...StatsOption) (*Tensor[T], error) {
+	o := newStatsOptions(options, 1)
+	if len(o.axes) > 0 || o.keepDims || o.interpolation != InterpolationLinear {
+		return nil, fmt.Errorf("covariance only supports StatsDDOF option")
+	}
+
+	return covariance(input, o, false)
+}
+
+// CovarianceScalar computes covariance of two vectors of same length.
+// See Covariance for details
+func CovarianceScalar[T FloatTypes](x, y *Tensor[T], options ...StatsOption) (*Scalar[T], error) {
+	input, err := stackVectors(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := Covariance(input, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScalar(output.value[1]), nil
+}
+
+// Correlation computes Pearson correlation coefficient matrix of input
+// of shape [samples, features], resulting in shape [features, features].
+// Coefficients involving features with zero variance are NaN. Unlike
+// Covariance it takes no options, since ddof cancels out
+func Correlation[T FloatTypes](input *Tensor[T]) (*Tensor[T], error) {
+	return covariance(input, newStatsOptions(nil, 1), true)
+}
+
+// CorrelationScalar computes Pearson correlation coefficient of two
+// vectors of same length
+func CorrelationScalar[T FloatTypes](x, y *Tensor[T]) (*Scalar[T], error) {
+	input, err := stackVectors(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := Correlation(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScalar(output.value[1]), nil
+}
+
+// covariance computes covariance or correlation matrix of input
+func covariance[T FloatTypes](input *Tensor[T], o *statsOptions, normalize bool) (*Tensor[T], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	if len(input.shape) != 2 {
+		return nil, fmt.Errorf("input needs to be of shape [samples, features], got %v", input.shape)
+	}
+
+	n := input.shape[0]
+	if n-o.ddof <= 0 {
+		return nil, fmt.Errorf("ddof %d needs to be less than number of samples %d", o.ddof, n)
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+
+	Centered := op.Sub(
+		root,
+		X,
+		op.Mean(root, X, op.Const(root.SubScope("axis"), int32(0)), op.MeanKeepDims(true)),
+	)
+
+	Output := op.Div(
+		root,
+		op.MatMul(root, Centered, Centered, op.MatMulTransposeA(true)),
+		op.Const(root.SubScope("n"), T(n-o.ddof)),
+	)
+
+	if normalize {
+		// divide by outer product of standard deviations
+		std := op.Sqrt(root, op.MatrixDiagPart(root, Output))
+		Output = op.Div(
+			root,
+			Output,
+			op.Mul(
+				root,
+				op.ExpandDims(root, std, op.Const(root.SubScope("col"), int32(1))),
+				op.ExpandDims(root, std, op.Const(root.SubScope("row"), int32(0))),
+			),
+		)
+	}
+
+	return runOutput[T](root, map[tf.Output]*tf.Tensor{X: x}, Output)
+}
+
+// stackVectors stacks two vectors of same length as columns of a matrix
+func stackVectors[T FloatTypes](x, y *Tensor[T]) (*Tensor[T], error) {
+	if x == nil || y == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(x.shape) != 1 || len(y.shape) != 1 || x.shape[0] != y.shape[0] {
+		return nil, fmt.Errorf("inputs need to be vectors of same length, got shapes %v and %v", x.shape, y.shape)
+	}
+
+	values := make([]T, 0, 2*len(x.value))
+	for i := range x.value {
+		values = append(values, x.value[i], y.value[i])
+	}
+
+	return NewTensor(values, len(x.value), 2)
+}
@@ -0,0 +1,201 @@
+package tfutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMoments(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mean, variance, err := Moments(x, StatsAxes(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(mean.value, []float64{2.5, 3.5, 4.5}, 1e-12) || !equal(mean.shape, []int{3}) {
+		t.Fatal("mean does not match expected value")
+	}
+
+	if !allClose(variance.value, []float64{2.25, 2.25, 2.25}, 1e-12) {
+		t.Fatal("variance does not match expected value")
+	}
+
+	std, err := Std(x, StatsAxes(1), StatsDDOF(1), StatsKeepDims(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(std.value, []float64{1, 1}, 1e-12) || !equal(std.shape, []int{2, 1}) {
+		t.Fatal("std does not match expected value")
+	}
+
+	m, v, err := MomentsScalar(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(m.Value()-3.5) > 1e-12 || math.Abs(v.Value()-35.0/12) > 1e-12 {
+		t.Fatal("scalar moments do not match expected value")
+	}
+
+	s, err := StdScalar(x, StatsDDOF(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(s.Value()-math.Sqrt(3.5)) > 1e-12 {
+		t.Fatal("scalar std does not match expected value")
+	}
+
+	if _, _, err := Moments(x, StatsAxes(0), StatsDDOF(2)); err == nil {
+		t.Fatal("expected moments to fail for ddof not less than number of elements")
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 3, 4, 10, 40, 20, 30}, 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := Quantile(x, []float64{0, 0.5, 1}, StatsAxes(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(q.value, []float64{1, 10, 2.5, 25, 4, 40}, 1e-12) || !equal(q.shape, []int{3, 2}) {
+		t.Fatal("quantile output does not match expected value")
+	}
+
+	tests := []struct {
+		interpolation Interpolation
+		expected      float64
+	}{
+		{InterpolationLinear, 2.75},
+		{InterpolationLower, 2},
+		{InterpolationHigher, 3},
+		{InterpolationNearest, 3},
+		{InterpolationMidpoint, 2.5},
+	}
+
+	// 0.25 quantile over all elements lies between 2 and 3
+	for _, test := range tests {
+		q, err := QuantileScalar(x, 0.25, StatsInterpolation(test.interpolation), StatsAxes(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if math.Abs(q.Value()-test.expected) > 1e-12 {
+			t.Fatalf("quantile with interpolation %d does not match expected value, got %v", test.interpolation, q.Value())
+		}
+	}
+
+	p, err := Percentile(x, []float64{50}, StatsAxes(0), StatsKeepDims(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(p.value, []float64{5.5, 21, 11.5, 17}, 1e-12) || !equal(p.shape, []int{1, 1, 4}) {
+		t.Fatal("percentile output does not match expected value")
+	}
+
+	y, err := NewTensor([]float32{1, float32(math.NaN()), 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	median, err := PercentileScalar(y, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !math.IsNaN(float64(median.Value())) {
+		t.Fatal("expected median of values with NaN to be NaN")
+	}
+
+	if _, err := Quantile(x, []float64{1.5}); err == nil {
+		t.Fatal("expected quantile to fail for out of range quantile")
+	}
+}
+
+func TestHistogramFixedWidth(t *testing.T) {
+	x, err := NewTensor([]float32{-1, 0, 0.5, 1, 2, 5, 10, 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := HistogramFixedWidth(x, 0, 10, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(h.value, []int64{4, 1, 1, 0, 2}) || !equal(h.shape, []int{5}) {
+		t.Fatal("histogram does not match expected value")
+	}
+}
+
+func TestCovarianceCorrelation(t *testing.T) {
+	x, err := NewTensor([]float64{1, 2, 2, 4, 3, 6, 4, 8}, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cov, err := Covariance(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := 5.0 / 3
+	if !allClose(cov.value, []float64{v, 2 * v, 2 * v, 4 * v}, 1e-12) || !equal(cov.shape, []int{2, 2}) {
+		t.Fatal("covariance does not match expected value")
+	}
+
+	corr, err := Correlation(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !allClose(corr.value, []float64{1, 1, 1, 1}, 1e-12) {
+		t.Fatal("correlation does not match expected value")
+	}
+
+	a, err := NewTensor([]float64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewTensor([]float64{3, 2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := CorrelationScalar(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(r.Value()+1) > 1e-12 {
+		t.Fatal("scalar correlation does not match expected value")
+	}
+
+	c, err := CovarianceScalar(a, b, StatsDDOF(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(c.Value()+2.0/3) > 1e-12 {
+		t.Fatal("scalar covariance does not match expected value")
+	}
+
+	if _, err := Covariance(x, StatsAxes(1)); err == nil {
+		t.Fatal("expected covariance to fail for axes option")
+	}
+
+	if _, err := CovarianceScalar(a, b, StatsKeepDims(true)); err == nil {
+		t.Fatal("expected scalar covariance to fail for keep dims option")
+	}
+}
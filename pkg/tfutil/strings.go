@@ -0,0 +1,270 @@
+package tfutil
+
+import (
+	"fmt"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// StringUnit defines how positions and lengths within strings are counted
+type StringUnit string
+
+const (
+	// StringUnitByte counts bytes
+	StringUnitByte StringUnit = "BYTE"
+	// StringUnitUTF8Char counts UTF-8 encoded unicode code points
+	StringUnitUTF8Char StringUnit = "UTF8_CHAR"
+)
+
+// StringSplit splits each element of input by sep into tokens, splitting at
+// most maxSplit times per element for positive maxSplit. An empty sep
+// splits on runs of whitespace and drops empty tokens. Output is ragged
+// and consists of tokens of all elements flattened in row major order and
+// a tensor of shape of input holding number of tokens per element. values
+// is nil if there are no tokens
+func StringSplit(input *Tensor[string], sep string, maxSplit int) (values *Tensor[string], rowLengths *Tensor[int64], err error) {
+	if input == nil {
+		return nil, nil, fmt.Errorf("input can't be nil")
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	if maxSplit <= 0 {
+		maxSplit = -1
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	indices, tokens, _ := op.StringSplitV2(
+		root,
+		op.Reshape(root, X, op.Const(root.SubScope("shape"), []int64{-1})),
+		op.Const(root.SubScope("sep"), sep),
+		op.StringSplitV2Maxsplit(int64(maxSplit)),
+	)
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, indices, tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// first column of sparse indices is the row of each token
+	rows, ok := out[0].Value().([][]int64)
+	if !ok {
+		return nil, nil, fmt.Errorf("expected indices of data type int64, got %T", out[0].Value())
+	}
+
+	lengths := make([]int64, len(input.value))
+	for _, index := range rows {
+		lengths[index[0]]++
+	}
+
+	return raggedOutput[string](out[1], lengths, input.shape)
+}
+
+// RegexReplace replaces matches of pattern in each element of input with
+// rewrite, which can refer to capture groups as \1 and so on. Only the
+// first match is replaced if global is false. pattern uses RE2 syntax
+func RegexReplace(input *Tensor[string], pattern, rewrite string, global bool) (*Tensor[string], error) {
+	return applyString[string](input, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.StaticRegexReplace(scope, x, pattern, rewrite, op.StaticRegexReplaceReplaceGlobal(global))
+	})
+}
+
+// RegexFullMatch checks whether each element of input matches pattern in
+// its entirety. pattern uses RE2 syntax
+func RegexFullMatch(input *Tensor[string], pattern string) (*Tensor[bool], error) {
+	return applyString[bool](input, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.StaticRegexFullMatch(scope, x, pattern)
+	})
+}
+
+// StringJoin joins corresponding elements of inputs of equal shapes with
+// separator
+func StringJoin(separator string, inputs ...*Tensor[string]) (*Tensor[string], error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("at least one input is needed")
+	}
+
+	for i, input := range inputs {
+		if input == nil {
+			return nil, fmt.Errorf("input %d can't be nil", i)
+		}
+
+		if !equal(input.shape, inputs[0].shape) {
+			return nil, fmt.Errorf("inputs need equal shapes, got %v and %v", inputs[0].shape, input.shape)
+		}
+	}
+
+	return Apply(
+		func(scope *op.Scope, outputs ...tf.Output) (tf.Output, error) {
+			return op.StringJoin(scope, outputs, op.StringJoinSeparator(separator)), nil
+		},
+		inputs...,
+	)
+}
+
+// Substr extracts substring of each element of input starting at pos of
+// given length, both counted in unit. Negative pos counts from the end of
+// an element. Substring is truncated at the end of an element and pos
+// needs to be within each element
+func Substr(input *Tensor[string], pos, length int, unit StringUnit) (*Tensor[string], error) {
+	if err := checkStringUnit(unit); err != nil {
+		return nil, err
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("length can't be negative, got %d", length)
+	}
+
+	return applyString[string](input, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.Substr(
+			scope,
+			x,
+			op.Const(scope.SubScope("pos"), int64(pos)),
+			op.Const(scope.SubScope("length"), int64(length)),
+			op.SubstrUnit(string(unit)),
+		)
+	})
+}
+
+// StringLower converts each element of input to lower case. Input needs
+// to be UTF-8 encoded
+func StringLower(input *Tensor[string]) (*Tensor[string], error) {
+	return applyString[string](input, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.StringLower(scope, x, op.StringLowerEncoding("utf-8"))
+	})
+}
+
+// StringUpper converts each element of input to upper case. Input needs
+// to be UTF-8 encoded
+func StringUpper(input *Tensor[string]) (*Tensor[string], error) {
+	return applyString[string](input, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.StringUpper(scope, x, op.StringUpperEncoding("utf-8"))
+	})
+}
+
+// StringStrip removes leading and trailing whitespace from each element
+// of input
+func StringStrip(input *Tensor[string]) (*Tensor[string], error) {
+	return applyString[string](input, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.StringStrip(scope, x)
+	})
+}
+
+// StringLength computes length of each element of input counted in unit
+func StringLength(input *Tensor[string], unit StringUnit) (*Tensor[int32], error) {
+	if err := checkStringUnit(unit); err != nil {
+		return nil, err
+	}
+
+	return applyString[int32](input, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.StringLength(scope, x, op.StringLengthUnit(string(unit)))
+	})
+}
+
+// UnicodeDecode decodes each UTF-8 encoded element of input into unicode
+// code points, with invalid sequences replaced by U+FFFD. Output is
+// ragged and consists of code points of all elements flattened in row
+// major order and a tensor of shape of input holding number of code
+// points per element. codePoints is nil if all elements are empty
+func UnicodeDecode(input *Tensor[string]) (codePoints *Tensor[int32], rowLengths *Tensor[int64], err error) {
+	if input == nil {
+		return nil, nil, fmt.Errorf("input can't be nil")
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+	splits, chars := op.UnicodeDecode(
+		root,
+		op.Reshape(root, X, op.Const(root.SubScope("shape"), []int64{-1})),
+		"UTF-8",
+		op.UnicodeDecodeTsplits(tf.Int64),
+	)
+
+	out, err := runSession(root, map[tf.Output]*tf.Tensor{X: x}, splits, chars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rowSplits, ok := out[0].Value().([]int64)
+	if !ok {
+		return nil, nil, fmt.Errorf("expected row splits of data type int64, got %T", out[0].Value())
+	}
+
+	lengths := make([]int64, len(rowSplits)-1)
+	for i := range lengths {
+		lengths[i] = rowSplits[i+1] - rowSplits[i]
+	}
+
+	return raggedOutput[int32](out[1], lengths, input.shape)
+}
+
+// StringToHashBucketFast hashes each element of input into one of
+// numBuckets buckets. Hash is deterministic and matches that of
+// tensorflow, but is not cryptographically secure
+func StringToHashBucketFast(input *Tensor[string], numBuckets int) (*Tensor[int64], error) {
+	if numBuckets <= 0 {
+		return nil, fmt.Errorf("numBuckets needs to be positive, got %d", numBuckets)
+	}
+
+	return applyString[int64](input, func(scope *op.Scope, x tf.Output) tf.Output {
+		return op.StringToHashBucketFast(scope, x, int64(numBuckets))
+	})
+}
+
+// applyString applies f over input producing output of data type O with
+// shape of input
+func applyString[O PrimitiveTypes](input *Tensor[string], f func(scope *op.Scope, x tf.Output) tf.Output) (*Tensor[O], error) {
+	if input == nil {
+		return nil, fmt.Errorf("input can't be nil")
+	}
+
+	x, err := input.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	root := op.NewScope()
+	X := placeholder(root, "X", x)
+
+	return runOutput[O](root, map[tf.Output]*tf.Tensor{X: x}, f(root, X))
+}
+
+// raggedOutput unmarshals flat values of a ragged output, which are nil
+// if empty, along with row lengths shaped as input
+func raggedOutput[T PrimitiveTypes](tfTensor *tf.Tensor, lengths []int64, shape []int) (*Tensor[T], *Tensor[int64], error) {
+	rowLengths, err := NewTensor(lengths, clone(shape)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if tfTensor.Shape()[0] == 0 {
+		return nil, rowLengths, nil
+	}
+
+	values, err := fromTfTensor[T](tfTensor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return values, rowLengths, nil
+}
+
+// checkStringUnit checks that unit is one of the defined units
+func checkStringUnit(unit StringUnit) error {
+	switch unit {
+	case StringUnitByte, StringUnitUTF8Char:
+		return nil
+	}
+
+	return fmt.Errorf("invalid string unit %q", unit)
+}
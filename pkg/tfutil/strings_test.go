@@ -0,0 +1,178 @@
+package tfutil
+
+import (
+	"testing"
+)
+
+func TestStringSplit(t *testing.T) {
+	input, err := NewTensor([]string{"a b  c", "", "d e", "f"}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, rowLengths, err := StringSplit(input, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(values.value, []string{"a", "b", "c", "d", "e", "f"}) {
+		t.Fatalf("expected values [a b c d e f], got %v", values.value)
+	}
+
+	if !equal(rowLengths.value, []int64{3, 0, 2, 1}) || !equal(rowLengths.shape, []int{2, 2}) {
+		t.Fatalf("expected row lengths [3 0 2 1], got %v", rowLengths.value)
+	}
+
+	csv, err := NewTensor([]string{"1,2,,3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// explicit separator keeps empty tokens
+	if values, rowLengths, err = StringSplit(csv, ",", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(values.value, []string{"1", "2", ",3"}) || !equal(rowLengths.value, []int64{3}) {
+		t.Fatalf("expected values [1 2 ,3], got %v", values.value)
+	}
+
+	empty, err := NewTensor([]string{""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, rowLengths, err = StringSplit(empty, "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if values != nil || !equal(rowLengths.value, []int64{0}) {
+		t.Fatal("expected no values for empty input")
+	}
+}
+
+func TestStringOps(t *testing.T) {
+	input, err := NewTensor([]string{"  Hello World ", "Ünïcode"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stripped, err := StringStrip(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(stripped.value, []string{"Hello World", "Ünïcode"}) {
+		t.Fatalf("unexpected stripped value %q", stripped.value)
+	}
+
+	lower, err := StringLower(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(lower.value, []string{"hello world", "ünïcode"}) {
+		t.Fatalf("unexpected lower case value %q", lower.value)
+	}
+
+	upper, err := StringUpper(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(upper.value, []string{"HELLO WORLD", "ÜNÏCODE"}) {
+		t.Fatalf("unexpected upper case value %q", upper.value)
+	}
+
+	bytes, err := StringLength(stripped, StringUnitByte)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chars, err := StringLength(stripped, StringUnitUTF8Char)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(bytes.value, []int32{11, 9}) || !equal(chars.value, []int32{11, 7}) {
+		t.Fatalf("unexpected lengths %v and %v", bytes.value, chars.value)
+	}
+
+	sub, err := Substr(stripped, 0, 3, StringUnitUTF8Char)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(sub.value, []string{"Hel", "Ünï"}) {
+		t.Fatalf("unexpected substring %q", sub.value)
+	}
+
+	replaced, err := RegexReplace(stripped, `(\w+) (\w+)`, `\2 \1`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(replaced.value, []string{"World Hello", "Ünïcode"}) {
+		t.Fatalf("unexpected replaced value %q", replaced.value)
+	}
+
+	matched, err := RegexFullMatch(stripped, `H.*d`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(matched.value, []bool{true, false}) {
+		t.Fatalf("unexpected match %v", matched.value)
+	}
+
+	joined, err := StringJoin("-", stripped, lower)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(joined.value, []string{"Hello World-hello world", "Ünïcode-ünïcode"}) {
+		t.Fatalf("unexpected joined value %q", joined.value)
+	}
+
+	if _, err := Substr(stripped, 0, 3, "WORD"); err == nil {
+		t.Fatal("expected substring to fail for invalid unit")
+	}
+}
+
+func TestUnicodeDecode(t *testing.T) {
+	input, err := NewTensor([]string{"aé", "", "€"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codePoints, rowLengths, err := UnicodeDecode(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(codePoints.value, []int32{'a', 'é', '€'}) || !equal(rowLengths.value, []int64{2, 0, 1}) {
+		t.Fatalf("unexpected code points %v with row lengths %v", codePoints.value, rowLengths.value)
+	}
+}
+
+func TestStringToHashBucketFast(t *testing.T) {
+	input, err := NewTensor([]string{"a", "b", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buckets, err := StringToHashBucketFast(input, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buckets.value[0] != buckets.value[2] {
+		t.Fatal("expected equal strings to hash to the same bucket")
+	}
+
+	for _, bucket := range buckets.value {
+		if bucket < 0 || bucket >= 10 {
+			t.Fatalf("bucket %d out of range", bucket)
+		}
+	}
+}
@@ -58,6 +58,39 @@ type PrimitiveTypes interface {
 		string
 }
 
+// IntegerTypes are type constraints for integer subset of PrimitiveTypes
+type IntegerTypes interface {
+	int8 | int16 | int32 | int64 |
+		uint8 | uint16 | uint32 | uint64
+}
+
+// FloatTypes are type constraints for floating point subset of PrimitiveTypes
+type FloatTypes interface {
+	float32 | float64
+}
+
+// ComplexTypes are type constraints for complex subset of PrimitiveTypes
+type ComplexTypes interface {
+	complex64 | complex128
+}
+
+// RealTypes are type constraints for numeric types that are not complex
+type RealTypes interface {
+	IntegerTypes | FloatTypes
+}
+
+// InexactTypes are type constraints for floating point and complex
+// types, which are needed by operations such as matrix decompositions
+type InexactTypes interface {
+	FloatTypes | ComplexTypes
+}
+
+// NumericTypes are type constraints for all numeric types, i.e.,
+// PrimitiveTypes excluding bool and string
+type NumericTypes interface {
+	RealTypes | ComplexTypes
+}
+
 // Tensor is a generic non-scalar data structures that includes
 // vectors, matrices and higher dimensional structures.
 // Tensor's representation is a slice because it is easier
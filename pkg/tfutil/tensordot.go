@@ -0,0 +1,184 @@
+package tfutil
+
+import (
+	"fmt"
+)
+
+// Tensordot contracts input tensors over pairs of axes, i.e., sums products
+// of elements of a and b along axesA[i] of a and axesB[i] of b for all i.
+// Output shape consists of non-contracted dimensions of a followed by
+// non-contracted dimensions of b. Negative axes count from the last
+// dimension. When all dimensions are contracted output has shape [1].
+// Contraction is performed in Go, so every numeric data type is supported
+func Tensordot[T NumericTypes](a, b *Tensor[T], axesA, axesB []int) (*Tensor[T], error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(axesA) != len(axesB) {
+		return nil, fmt.Errorf("axes need to be of same length, got %d and %d", len(axesA), len(axesB))
+	}
+
+	contractedA, freeA, err := splitAxes(axesA, len(a.shape))
+	if err != nil {
+		return nil, fmt.Errorf("invalid axes of first input: %w", err)
+	}
+
+	contractedB, freeB, err := splitAxes(axesB, len(b.shape))
+	if err != nil {
+		return nil, fmt.Errorf("invalid axes of second input: %w", err)
+	}
+
+	k := 1
+	for i := range contractedA {
+		dimA, dimB := a.shape[contractedA[i]], b.shape[contractedB[i]]
+		if dimA != dimB {
+			return nil, fmt.Errorf(
+				"axis %d of first input of shape %v has dimension %d, but axis %d of second input of shape %v has dimension %d",
+				contractedA[i], a.shape, dimA, contractedB[i], b.shape, dimB,
+			)
+		}
+		k *= dimA
+	}
+
+	shape := make([]int, 0, len(freeA)+len(freeB))
+	for _, axis := range freeA {
+		shape = append(shape, a.shape[axis])
+	}
+	for _, axis := range freeB {
+		shape = append(shape, b.shape[axis])
+	}
+
+	// a is permuted to a matrix of shape [m, k] and b to a matrix
+	// of shape [k, n], reducing contraction to matrix multiplication
+	x := transposeValues(a.value, a.shape, append(clone(freeA), contractedA...))
+	y := transposeValues(b.value, b.shape, append(clone(contractedB), freeB...))
+	m, n := len(x)/k, len(y)/k
+
+	values := make([]T, m*n)
+	for i := 0; i < m; i++ {
+		row := values[i*n : (i+1)*n]
+		for l := 0; l < k; l++ {
+			v := x[i*k+l]
+			for j, w := range y[l*n : (l+1)*n] {
+				row[j] += v * w
+			}
+		}
+	}
+
+	if len(shape) == 0 {
+		shape = []int{1}
+	}
+
+	return NewTensor(values, shape...)
+}
+
+// Outer computes outer product of two vectors of lengths m and n
+// resulting in a matrix of shape [m, n]
+func Outer[T NumericTypes](x, y *Tensor[T]) (*Tensor[T], error) {
+	if x == nil || y == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(x.shape) != 1 || len(y.shape) != 1 {
+		return nil, fmt.Errorf("inputs need to be vectors, got shapes %v and %v", x.shape, y.shape)
+	}
+
+	return Tensordot(x, y, nil, nil)
+}
+
+// Kron computes Kronecker product of two matrices of shapes [m, n] and
+// [p, q] resulting in a block matrix of shape [m*p, n*q], in which
+// block (i, j) is y scaled by element (i, j) of x
+func Kron[T NumericTypes](x, y *Tensor[T]) (*Tensor[T], error) {
+	if x == nil || y == nil {
+		return nil, fmt.Errorf("inputs can't be nil")
+	}
+
+	if len(x.shape) != 2 || len(y.shape) != 2 {
+		return nil, fmt.Errorf("inputs need to be matrices, got shapes %v and %v", x.shape, y.shape)
+	}
+
+	m, n := x.shape[0], x.shape[1]
+	p, q := y.shape[0], y.shape[1]
+	cols := n * q
+
+	values := make([]T, m*p*cols)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			v := x.value[i*n+j]
+			for r := 0; r < p; r++ {
+				offset := (i*p+r)*cols + j*q
+				for c := 0; c < q; c++ {
+					values[offset+c] = v * y.value[r*q+c]
+				}
+			}
+		}
+	}
+
+	return NewTensor(values, m*p, cols)
+}
+
+// splitAxes normalizes axes for given rank and returns them along with
+// remaining axes in increasing order. Axes can't be repeated
+func splitAxes(axes []int, rank int) ([]int, []int, error) {
+	seen := make([]bool, rank)
+	normalized := make([]int, len(axes))
+	for i, axis := range axes {
+		axis, err := normalizeAxis(axis, rank)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if seen[axis] {
+			return nil, nil, fmt.Errorf("axis %d is repeated", axes[i])
+		}
+
+		seen[axis] = true
+		normalized[i] = axis
+	}
+
+	free := make([]int, 0, rank-len(axes))
+	for axis := range seen {
+		if !seen[axis] {
+			free = append(free, axis)
+		}
+	}
+
+	return normalized, free, nil
+}
+
+// transposeValues returns values of a row-major tensor of input shape
+// with dimensions permuted such that i-th output dimension is perm[i]-th
+// input dimension
+func transposeValues[T PrimitiveTypes](values []T, shape, perm []int) []T {
+	strides := make([]int, len(shape))
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = 1
+		if i < len(shape)-1 {
+			strides[i] = shape[i+1] * strides[i+1]
+		}
+	}
+
+	// index holds output coordinates, which are advanced in row-major
+	// order while tracking corresponding offset in input values
+	output := make([]T, len(values))
+	index := make([]int, len(perm))
+	offset := 0
+	for i := range output {
+		output[i] = values[offset]
+
+		for d := len(perm) - 1; d >= 0; d-- {
+			index[d]++
+			offset += strides[perm[d]]
+			if index[d] < shape[perm[d]] {
+				break
+			}
+
+			offset -= index[d] * strides[perm[d]]
+			index[d] = 0
+		}
+	}
+
+	return output
+}
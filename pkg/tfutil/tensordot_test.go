@@ -0,0 +1,95 @@
+package tfutil
+
+import (
+	"testing"
+)
+
+func TestTensordot(t *testing.T) {
+	a, err := NewTensorFromFunc(func(i int) int64 { return int64(i) }, 2, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewTensorFromFunc(func(i int) int64 { return int64(i) }, 4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// contract axis 1 of a with axis 1 of b and axis 2 of a with axis 0 of b
+	c, err := Tensordot(a, b, []int{1, -1}, []int{1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := make([]int64, 2)
+	for i := range expected {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 4; k++ {
+				expected[i] += a.value[i*12+j*4+k] * b.value[k*3+j]
+			}
+		}
+	}
+
+	if !equal(c.value, expected) || !equal(c.shape, []int{2}) {
+		t.Fatal("tensordot output does not match expected value")
+	}
+
+	d, err := Tensordot(a, b, []int{2}, []int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(d.shape, []int{2, 3, 3}) || d.value[5] != 4*2+5*5+6*8+7*11 {
+		t.Fatal("tensordot output does not match expected value")
+	}
+
+	if _, err := Tensordot(a, b, []int{0}, []int{0}); err == nil {
+		t.Fatal("expected tensordot to fail for mismatched dimensions")
+	}
+
+	if _, err := Tensordot(a, b, []int{2, 2}, []int{0, 1}); err == nil {
+		t.Fatal("expected tensordot to fail for repeated axes")
+	}
+}
+
+func TestOuter(t *testing.T) {
+	x, err := NewTensor([]uint8{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]uint8{4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := Outer(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(z.value, []uint8{4, 5, 8, 10, 12, 15}) || !equal(z.shape, []int{3, 2}) {
+		t.Fatal("outer output does not match expected value")
+	}
+}
+
+func TestKron(t *testing.T) {
+	x, err := NewTensor([]complex64{1, 2i}, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	y, err := NewTensor([]complex64{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := Kron(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(z.value, []complex64{1, 2, 2i, 4i, 3, 4, 6i, 8i}) || !equal(z.shape, []int{2, 4}) {
+		t.Fatal("kron output does not match expected value")
+	}
+}
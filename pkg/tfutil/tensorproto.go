@@ -0,0 +1,328 @@
+package tfutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/wamuir/graft/tensorflow/core/framework/tensor_go_proto"
+	"github.com/wamuir/graft/tensorflow/core/framework/tensor_shape_go_proto"
+	"github.com/wamuir/graft/tensorflow/core/framework/types_go_proto"
+)
+
+// maxTensorProtoSize bounds size in bytes of values expanded from
+// repeated fields. It matches the 2GiB limit of serialized protos, so
+// that shape of a corrupt proto can't trigger a huge allocation
+const maxTensorProtoSize = 1 << 31
+
+// ToTensorProto converts tensor to a tensor proto. Numeric and bool values
+// are written to tensor_content, except when all values are equal, in
+// which case a single value is written to the repeated field matching data
+// type, which tensorflow expands to the full shape. Strings are always
+// written to string_val
+func (tensor *Tensor[T]) ToTensorProto() (*tensor_go_proto.TensorProto, error) {
+	dims := make([]int64, len(tensor.shape))
+	for i, dim := range tensor.shape {
+		dims[i] = int64(dim)
+	}
+
+	return toTensorProto(tensor.value, dims)
+}
+
+// FromTensorProto populates tensor from a tensor proto holding values
+// either in tensor_content or in the repeated field matching data type.
+// Repeated fields holding fewer values than the number of elements are
+// padded with their last value, or zero values if empty, as in
+// tensorflow, up to 2GiB of values. Data type of tensor proto needs to match T. A scalar tensor
+// proto is read as a vector of length 1
+func (tensor *Tensor[T]) FromTensorProto(p *tensor_go_proto.TensorProto) error {
+	values, dims, err := fromTensorProto[T](p)
+	if err != nil {
+		return err
+	}
+
+	shape := make([]int, len(dims))
+	for i, dim := range dims {
+		shape[i] = int(dim)
+	}
+
+	if len(shape) == 0 {
+		shape = []int{1}
+	}
+
+	if err := checkShape(shape); err != nil {
+		return fmt.Errorf("invalid tensor proto shape %v: %w", dims, err)
+	}
+
+	tensor.value = values
+	tensor.shape = shape
+
+	return nil
+}
+
+// ToTensorProto converts scalar to a tensor proto of scalar shape. See
+// Tensor.ToTensorProto for details
+func (g *Scalar[T]) ToTensorProto() (*tensor_go_proto.TensorProto, error) {
+	return toTensorProto([]T{g.value}, nil)
+}
+
+// FromTensorProto populates scalar from a tensor proto holding a single
+// element. See Tensor.FromTensorProto for details
+func (g *Scalar[T]) FromTensorProto(p *tensor_go_proto.TensorProto) error {
+	values, dims, err := fromTensorProto[T](p)
+	if err != nil {
+		return err
+	}
+
+	if len(values) != 1 {
+		return fmt.Errorf("scalar needs tensor proto with one element, got shape %v", dims)
+	}
+
+	g.value = values[0]
+	return nil
+}
+
+// toTensorProto converts values of shape dims to a tensor proto
+func toTensorProto[T PrimitiveTypes](values []T, dims []int64) (*tensor_go_proto.TensorProto, error) {
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	dim := make([]*tensor_shape_go_proto.TensorShapeProto_Dim, len(dims))
+	for i, size := range dims {
+		dim[i] = &tensor_shape_go_proto.TensorShapeProto_Dim{Size: size}
+	}
+
+	p := &tensor_go_proto.TensorProto{
+		Dtype:       types_go_proto.DataType(dataType),
+		TensorShape: &tensor_shape_go_proto.TensorShapeProto{Dim: dim},
+	}
+
+	if strs, ok := any(values).([]string); ok {
+		p.StringVal = make([][]byte, len(strs))
+		for i, s := range strs {
+			p.StringVal[i] = []byte(s)
+		}
+
+		return p, nil
+	}
+
+	// splat values are compacted to a single repeated value
+	splat := len(values) > 1
+	for _, v := range values {
+		if !identical(v, values[0]) {
+			splat = false
+			break
+		}
+	}
+
+	if !splat {
+		buf := &bytes.Buffer{}
+		if err := binary.Write(buf, binary.LittleEndian, values); err != nil {
+			return nil, fmt.Errorf("failed to serialize tensor contents: %w", err)
+		}
+		p.TensorContent = buf.Bytes()
+
+		return p, nil
+	}
+
+	switch v := any(values[0]).(type) {
+	case bool:
+		p.BoolVal = []bool{v}
+	case int8:
+		p.IntVal = []int32{int32(v)}
+	case int16:
+		p.IntVal = []int32{int32(v)}
+	case int32:
+		p.IntVal = []int32{v}
+	case int64:
+		p.Int64Val = []int64{v}
+	case uint8:
+		p.IntVal = []int32{int32(v)}
+	case uint16:
+		p.IntVal = []int32{int32(v)}
+	case uint32:
+		p.Uint32Val = []uint32{v}
+	case uint64:
+		p.Uint64Val = []uint64{v}
+	case float32:
+		p.FloatVal = []float32{v}
+	case float64:
+		p.DoubleVal = []float64{v}
+	case complex64:
+		p.ScomplexVal = []float32{real(v), imag(v)}
+	case complex128:
+		p.DcomplexVal = []float64{real(v), imag(v)}
+	}
+
+	return p, nil
+}
+
+// fromTensorProto decodes values and shape of a tensor proto
+func fromTensorProto[T PrimitiveTypes](p *tensor_go_proto.TensorProto) ([]T, []int64, error) {
+	if p == nil {
+		return nil, nil, fmt.Errorf("tensor proto can't be nil")
+	}
+
+	dataType, err := dataTypeOf[T]()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p.Dtype != types_go_proto.DataType(dataType) {
+		return nil, nil, fmt.Errorf("tensor proto data type %v does not match data type %T", p.Dtype, *new(T))
+	}
+
+	if p.TensorShape == nil || p.TensorShape.UnknownRank {
+		return nil, nil, fmt.Errorf("tensor proto needs a known shape")
+	}
+
+	dims := make([]int64, len(p.TensorShape.Dim))
+	n := 1
+	for i, dim := range p.TensorShape.Dim {
+		if dim.Size < 0 {
+			return nil, nil, fmt.Errorf("tensor proto needs a fully defined shape, got dimension %d of size %d", i, dim.Size)
+		}
+
+		if dim.Size > 0 && int64(n) > math.MaxInt/dim.Size {
+			return nil, nil, fmt.Errorf("tensor proto shape %v is too large", p.TensorShape)
+		}
+		dims[i] = dim.Size
+		n *= int(dim.Size)
+	}
+
+	// tensor content holds all values in little endian byte order and
+	// its length is checked before allocating values
+	if len(p.TensorContent) > 0 {
+		if _, ok := any(*new(T)).(string); ok {
+			return nil, nil, fmt.Errorf("tensor content is not supported for strings")
+		}
+
+		if size := binary.Size(*new(T)); len(p.TensorContent)%size != 0 || len(p.TensorContent)/size != n {
+			return nil, nil, fmt.Errorf("expected tensor content of %d elements of %d bytes for shape %v, got %d bytes", n, size, dims, len(p.TensorContent))
+		}
+
+		values := make([]T, n)
+
+		if err := binary.Read(bytes.NewReader(p.TensorContent), binary.LittleEndian, values); err != nil {
+			return nil, nil, fmt.Errorf("failed to deserialize tensor contents: %w", err)
+		}
+
+		return values, dims, nil
+	}
+
+	// values expanded from repeated fields are only bounded by shape,
+	// so their size is checked before allocating them
+	size := 16 // size of a string header
+	if _, ok := any(*new(T)).(string); !ok {
+		size = binary.Size(*new(T))
+	}
+
+	if n > maxTensorProtoSize/size {
+		return nil, nil, fmt.Errorf("tensor proto shape %v exceeds %d bytes of values", dims, maxTensorProtoSize)
+	}
+
+	values := make([]T, n)
+
+	var repeated []T
+	switch any(*new(T)).(type) {
+	case bool:
+		repeated = any(p.BoolVal).([]T)
+	case int8, int16, int32, uint8, uint16:
+		repeated = fromIntVal[T](p.IntVal)
+	case int64:
+		repeated = any(p.Int64Val).([]T)
+	case uint32:
+		repeated = any(p.Uint32Val).([]T)
+	case uint64:
+		repeated = any(p.Uint64Val).([]T)
+	case float32:
+		repeated = any(p.FloatVal).([]T)
+	case float64:
+		repeated = any(p.DoubleVal).([]T)
+	case complex64:
+		if len(p.ScomplexVal)%2 != 0 {
+			return nil, nil, fmt.Errorf("scomplex_val needs pairs of real and imaginary parts, got %d values", len(p.ScomplexVal))
+		}
+
+		c := make([]complex64, len(p.ScomplexVal)/2)
+		for i := range c {
+			c[i] = complex(p.ScomplexVal[2*i], p.ScomplexVal[2*i+1])
+		}
+		repeated = any(c).([]T)
+	case complex128:
+		if len(p.DcomplexVal)%2 != 0 {
+			return nil, nil, fmt.Errorf("dcomplex_val needs pairs of real and imaginary parts, got %d values", len(p.DcomplexVal))
+		}
+
+		c := make([]complex128, len(p.DcomplexVal)/2)
+		for i := range c {
+			c[i] = complex(p.DcomplexVal[2*i], p.DcomplexVal[2*i+1])
+		}
+		repeated = any(c).([]T)
+	case string:
+		s := make([]string, len(p.StringVal))
+		for i, b := range p.StringVal {
+			s[i] = string(b)
+		}
+		repeated = any(s).([]T)
+	}
+
+	if len(repeated) > n {
+		return nil, nil, fmt.Errorf("expected at most %d repeated values for shape %v, got %d", n, dims, len(repeated))
+	}
+
+	copy(values, repeated)
+	if len(repeated) > 0 {
+		for i := len(repeated); i < n; i++ {
+			values[i] = repeated[len(repeated)-1]
+		}
+	}
+
+	return values, dims, nil
+}
+
+// identical reports whether a and b have the same bit pattern, which
+// unlike == tells apart signed zeros of float and complex values
+func identical[T PrimitiveTypes](a, b T) bool {
+	switch x := any(a).(type) {
+	case float32:
+		return math.Float32bits(x) == math.Float32bits(any(b).(float32))
+	case float64:
+		return math.Float64bits(x) == math.Float64bits(any(b).(float64))
+	case complex64:
+		y := any(b).(complex64)
+		return math.Float32bits(real(x)) == math.Float32bits(real(y)) &&
+			math.Float32bits(imag(x)) == math.Float32bits(imag(y))
+	case complex128:
+		y := any(b).(complex128)
+		return math.Float64bits(real(x)) == math.Float64bits(real(y)) &&
+			math.Float64bits(imag(x)) == math.Float64bits(imag(y))
+	}
+
+	return a == b
+}
+
+// fromIntVal converts values of int_val, which holds all integer data
+// types narrower than 32 bits, to T
+func fromIntVal[T PrimitiveTypes](values []int32) []T {
+	output := make([]T, len(values))
+	for i, v := range values {
+		switch p := any(&output[i]).(type) {
+		case *int8:
+			*p = int8(v)
+		case *int16:
+			*p = int16(v)
+		case *int32:
+			*p = v
+		case *uint8:
+			*p = uint8(v)
+		case *uint16:
+			*p = uint16(v)
+		}
+	}
+
+	return output
+}
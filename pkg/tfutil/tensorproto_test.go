@@ -0,0 +1,191 @@
+package tfutil
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wamuir/graft/tensorflow/core/framework/tensor_go_proto"
+	"github.com/wamuir/graft/tensorflow/core/framework/tensor_shape_go_proto"
+	"github.com/wamuir/graft/tensorflow/core/framework/types_go_proto"
+)
+
+func TestTensorProtoRoundTrip(t *testing.T) {
+	input, err := NewTensor([]float32{1, 2, 3, 4, 5, 6}, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := input.ToTensorProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Dtype != types_go_proto.DataType_DT_FLOAT || len(p.TensorContent) != 24 || len(p.TensorShape.Dim) != 2 {
+		t.Fatalf("unexpected tensor proto %v", p)
+	}
+
+	output := &Tensor[float32]{}
+	if err := output.FromTensorProto(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(output.value, input.value) || !equal(output.shape, input.shape) {
+		t.Fatal("tensor proto round trip does not match input")
+	}
+
+	// splat values are compacted
+	splat, err := NewTensor([]complex64{1 + 2i, 1 + 2i, 1 + 2i, 1 + 2i}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = splat.ToTensorProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.TensorContent) != 0 || !equal(p.ScomplexVal, []float32{1, 2}) {
+		t.Fatalf("expected compacted tensor proto, got %v", p)
+	}
+
+	splatOut := &Tensor[complex64]{}
+	if err := splatOut.FromTensorProto(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(splatOut.value, splat.value) || !equal(splatOut.shape, splat.shape) {
+		t.Fatal("tensor proto round trip of splat does not match input")
+	}
+
+	// signed zeros are not compacted
+	zeros, err := NewTensor([]float64{0, math.Copysign(0, -1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = zeros.ToTensorProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zerosOut := &Tensor[float64]{}
+	if err := zerosOut.FromTensorProto(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Signbit(zerosOut.value[0]) || !math.Signbit(zerosOut.value[1]) {
+		t.Fatal("tensor proto round trip does not retain signed zeros")
+	}
+
+	strs, err := NewTensor([]string{"a", "bc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = strs.ToTensorProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strsOut := &Tensor[string]{}
+	if err := strsOut.FromTensorProto(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(strsOut.value, strs.value) {
+		t.Fatalf("tensor proto round trip of strings does not match input, got %q", strsOut.value)
+	}
+
+	scalar := NewScalar[int64](7)
+	p, err = scalar.ToTensorProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.TensorShape.Dim) != 0 {
+		t.Fatalf("expected scalar shape, got %v", p.TensorShape)
+	}
+
+	scalarOut := &Scalar[int64]{}
+	if err := scalarOut.FromTensorProto(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if scalarOut.Value() != 7 {
+		t.Fatalf("expected 7, got %d", scalarOut.Value())
+	}
+}
+
+func TestTensorProtoRepeatedValues(t *testing.T) {
+	shape := &tensor_shape_go_proto.TensorShapeProto{
+		Dim: []*tensor_shape_go_proto.TensorShapeProto_Dim{{Size: 2}, {Size: 2}},
+	}
+
+	// missing values repeat the last one
+	output := &Tensor[uint8]{}
+	if err := output.FromTensorProto(&tensor_go_proto.TensorProto{
+		Dtype:       types_go_proto.DataType_DT_UINT8,
+		TensorShape: shape,
+		IntVal:      []int32{3, 200},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(output.value, []uint8{3, 200, 200, 200}) {
+		t.Fatalf("unexpected values %v", output.value)
+	}
+
+	// no values mean zeros
+	zeros := &Tensor[bool]{}
+	if err := zeros.FromTensorProto(&tensor_go_proto.TensorProto{
+		Dtype:       types_go_proto.DataType_DT_BOOL,
+		TensorShape: shape,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(zeros.value, []bool{false, false, false, false}) {
+		t.Fatalf("unexpected values %v", zeros.value)
+	}
+
+	if err := output.FromTensorProto(&tensor_go_proto.TensorProto{
+		Dtype:       types_go_proto.DataType_DT_UINT8,
+		TensorShape: shape,
+		IntVal:      []int32{1, 2, 3, 4, 5},
+	}); err == nil {
+		t.Fatal("expected error for too many values")
+	}
+
+	if err := output.FromTensorProto(&tensor_go_proto.TensorProto{
+		Dtype:       types_go_proto.DataType_DT_INT32,
+		TensorShape: shape,
+	}); err == nil {
+		t.Fatal("expected error for mismatched data type")
+	}
+
+	if err := output.FromTensorProto(&tensor_go_proto.TensorProto{
+		Dtype:         types_go_proto.DataType_DT_UINT8,
+		TensorShape:   shape,
+		TensorContent: []byte{1, 2, 3},
+	}); err == nil {
+		t.Fatal("expected error for short tensor content")
+	}
+
+	if err := output.FromTensorProto(&tensor_go_proto.TensorProto{
+		Dtype: types_go_proto.DataType_DT_UINT8,
+		TensorShape: &tensor_shape_go_proto.TensorShapeProto{
+			Dim: []*tensor_shape_go_proto.TensorShapeProto_Dim{{Size: 1 << 32}, {Size: 1 << 32}},
+		},
+	}); err == nil {
+		t.Fatal("expected error for overflowing shape")
+	}
+
+	if err := output.FromTensorProto(&tensor_go_proto.TensorProto{
+		Dtype: types_go_proto.DataType_DT_UINT8,
+		TensorShape: &tensor_shape_go_proto.TensorShapeProto{
+			Dim: []*tensor_shape_go_proto.TensorShapeProto_Dim{{Size: 1 << 62}},
+		},
+	}); err == nil {
+		t.Fatal("expected error for shape exceeding size limit")
+	}
+}
@@ -2,7 +2,9 @@ package tfutil
 
 import (
 	"fmt"
+	"math"
 
+	tf "github.com/wamuir/graft/tensorflow"
 	"golang.org/x/exp/constraints"
 )
 
@@ -17,6 +19,17 @@ func castToInt64[T constraints.Integer](shape []T) []int64 {
 	return newShape
 }
 
+// castToInt32 is a generic function that can cast input
+// slice of integers to a slice of int32
+func castToInt32[T constraints.Integer](shape []T) []int32 {
+	newShape := make([]int32, len(shape))
+	for i, v := range shape {
+		newShape[i] = int32(v)
+	}
+
+	return newShape
+}
+
 // equal check element by element equality of two slices of
 // same type
 func equal[T PrimitiveTypes | int](x, y []T) bool {
@@ -60,8 +73,156 @@ func numElements(shape []int) (int, error) {
 		if shape[i] <= 0 {
 			return -1, fmt.Errorf("please provide positive shape values")
 		}
+		if shape[i] > math.MaxInt/n {
+			return -1, fmt.Errorf("shape %v has too many elements", shape)
+		}
 		n *= shape[i]
 	}
 
 	return n, nil
 }
+
+// normalizeAxis validates axis against rank and maps negative
+// values, which count from the last dimension, to positive ones
+func normalizeAxis(axis, rank int) (int, error) {
+	if axis < -rank || axis >= rank {
+		return 0, fmt.Errorf("axis %d is out of range for rank %d", axis, rank)
+	}
+
+	if axis < 0 {
+		axis += rank
+	}
+
+	return axis, nil
+}
+
+// checkAxis validates axis against the rank of output when
+// such rank is known at the time of graph construction
+func checkAxis(output tf.Output, axis int) error {
+	rank := output.Shape().NumDimensions()
+	if rank < 0 {
+		return nil
+	}
+
+	_, err := normalizeAxis(axis, rank)
+	return err
+}
+
+// checkShape validates shape of a tensor to be created. Shape needs
+// to have at least one dimension and all positive values
+func checkShape(shape []int) error {
+	if len(shape) == 0 {
+		return fmt.Errorf("shape needs at least one dimension")
+	}
+
+	if _, err := numElements(shape); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// dataTypeOf returns tensorflow data type corresponding to go data type T
+func dataTypeOf[T PrimitiveTypes]() (tf.DataType, error) {
+	tfTensor, err := tf.NewTensor(*new(T))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	return tfTensor.DataType(), nil
+}
+
+// broadcastShapes computes shape resulting from broadcasting input shapes
+// against each other following numpy rules, i.e., shapes are aligned at
+// the last dimension and each pair of dimensions needs to be either equal
+// or one of them needs to be 1. Negative values denote unknown dimensions
+// and are assumed to be compatible
+func broadcastShapes(x, y []int64) ([]int64, error) {
+	n := max(len(x), len(y))
+	shape := make([]int64, n)
+	for i := 1; i <= n; i++ {
+		a, b := int64(1), int64(1)
+		if i <= len(x) {
+			a = x[len(x)-i]
+		}
+		if i <= len(y) {
+			b = y[len(y)-i]
+		}
+
+		switch {
+		case a == b || b == 1:
+			shape[n-i] = a
+		case a == 1:
+			shape[n-i] = b
+		case a < 0 || b < 0:
+			shape[n-i] = max(a, b)
+		default:
+			return nil, fmt.Errorf("shapes %v and %v can't be broadcast, dimension %d is %d and %d", x, y, n-i, a, b)
+		}
+	}
+
+	return shape, nil
+}
+
+// integerType returns bit size and signedness of T if it is an integer type
+func integerType[T PrimitiveTypes]() (bits int, signed bool, ok bool) {
+	switch any(*new(T)).(type) {
+	case int8:
+		return 8, true, true
+	case int16:
+		return 16, true, true
+	case int32:
+		return 32, true, true
+	case int64:
+		return 64, true, true
+	case uint8:
+		return 8, false, true
+	case uint16:
+		return 16, false, true
+	case uint32:
+		return 32, false, true
+	case uint64:
+		return 64, false, true
+	default:
+		return 0, false, false
+	}
+}
+
+// setInteger sets p of an integer data type to v, which is in range
+func setInteger[T PrimitiveTypes, V int64 | uint64](p *T, v V) {
+	switch p := any(p).(type) {
+	case *int8:
+		*p = int8(v)
+	case *int16:
+		*p = int16(v)
+	case *int32:
+		*p = int32(v)
+	case *int64:
+		*p = int64(v)
+	case *uint8:
+		*p = uint8(v)
+	case *uint16:
+		*p = uint16(v)
+	case *uint32:
+		*p = uint32(v)
+	case *uint64:
+		*p = uint64(v)
+	}
+}
+
+// checkComplexPair checks that complex type C matches precision of float
+// type F
+func checkComplexPair[C ComplexTypes, F FloatTypes]() error {
+	switch any(*new(F)).(type) {
+	case float32:
+		if _, ok := any(*new(C)).(complex64); ok {
+			return nil
+		}
+	case float64:
+		if _, ok := any(*new(C)).(complex128); ok {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("complex data type %T does not match precision of float data type %T", *new(C), *new(F))
+}
@@ -0,0 +1,288 @@
+package tfutil
+
+import (
+	"fmt"
+	"sync"
+
+	tf "github.com/wamuir/graft/tensorflow"
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+// Session owns a long-lived tf session and its graph, which hold the
+// state of variables created in it between runs. Unlike other functions
+// in this package that create and close a session per call, a Session
+// needs to be closed explicitly, which releases its variables
+type Session struct {
+	mu         sync.Mutex
+	graph      *tf.Graph
+	sess       *tf.Session
+	extensions int
+	closed     bool
+}
+
+// Variable is a tensor whose value persists in a Session between runs.
+// Its data type and shape are fixed at creation
+type Variable[T PrimitiveTypes] struct {
+	session   *Session
+	shape     []int
+	handle    tf.Output
+	read      tf.Output
+	value     tf.Output
+	assign    *tf.Operation
+	assignAdd *tf.Operation
+}
+
+// NewSession creates a new session with an empty graph
+func NewSession() (*Session, error) {
+	graph := tf.NewGraph()
+	sess, err := tf.NewSession(
+		graph,
+		&tf.SessionOptions{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new tf session: %w", err)
+	}
+
+	return &Session{
+		graph: graph,
+		sess:  sess,
+	}, nil
+}
+
+// Close closes the session releasing all variables created in it.
+// Variables can't be used after session is closed
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if err := s.sess.Close(); err != nil {
+		return fmt.Errorf("failed to close tf session: %w", err)
+	}
+
+	return nil
+}
+
+// extend adds ops to the session graph via f under a new namespace.
+// Graph is extended in place and existing ops remain valid. Each
+// extension gets its own scope, so that a failed extension does not
+// leave an error on scope of later ones
+func (s *Session) extend(namespace string, f func(scope *op.Scope)) error {
+	if s.closed {
+		return fmt.Errorf("session is closed")
+	}
+
+	scope := op.NewScopeWithGraph(s.graph).SubScope(fmt.Sprintf("%s_%d", namespace, s.extensions))
+	s.extensions++
+
+	f(scope)
+	if err := scope.Err(); err != nil {
+		return fmt.Errorf("failed to extend graph: %w", err)
+	}
+
+	return nil
+}
+
+// run runs ops in the session feeding feeds and fetching fetches
+func (s *Session) run(feeds map[tf.Output]*tf.Tensor, fetches []tf.Output, targets ...*tf.Operation) ([]*tf.Tensor, error) {
+	if s.closed {
+		return nil, fmt.Errorf("session is closed")
+	}
+
+	out, err := s.sess.Run(feeds, fetches, targets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run tf session: %w", err)
+	}
+
+	if len(out) != len(fetches) {
+		return nil, fmt.Errorf("expected session run output to have length %d, got %d", len(fetches), len(out))
+	}
+
+	return out, nil
+}
+
+// NewVariable creates a new variable in session initialized to the value
+// of initial, which also sets data type and shape of variable
+func NewVariable[T PrimitiveTypes](session *Session, initial *Tensor[T]) (*Variable[T], error) {
+	if session == nil {
+		return nil, fmt.Errorf("session can't be nil")
+	}
+
+	if initial == nil {
+		return nil, fmt.Errorf("initial value can't be nil")
+	}
+
+	x, err := initial.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	variable := &Variable[T]{
+		session: session,
+		shape:   clone(initial.shape),
+	}
+
+	if err := session.extend("variable", func(scope *op.Scope) {
+		variable.handle = op.VarHandleOp(scope, x.DataType(), tf.MakeShape(x.Shape()...))
+		variable.read = op.ReadVariableOp(scope, variable.handle, x.DataType())
+		variable.value = placeholder(scope, "value", x)
+		variable.assign = op.AssignVariableOp(scope, variable.handle, variable.value)
+	}); err != nil {
+		return nil, err
+	}
+
+	if _, err := session.run(map[tf.Output]*tf.Tensor{variable.value: x}, nil, variable.assign); err != nil {
+		return nil, fmt.Errorf("failed to initialize variable: %w", err)
+	}
+
+	return variable, nil
+}
+
+// Shape returns shape of variable
+func (v *Variable[T]) Shape() []int {
+	return clone(v.shape)
+}
+
+// Read returns current value of variable as a new tensor
+func (v *Variable[T]) Read() (*Tensor[T], error) {
+	v.session.mu.Lock()
+	defer v.session.mu.Unlock()
+
+	out, err := v.session.run(nil, []tf.Output{v.read})
+	if err != nil {
+		return nil, err
+	}
+
+	return fromTfTensor[T](out[0])
+}
+
+// Assign sets variable to value, which needs to match variable shape
+func (v *Variable[T]) Assign(value *Tensor[T]) error {
+	x, err := v.marshalValue(value)
+	if err != nil {
+		return err
+	}
+
+	v.session.mu.Lock()
+	defer v.session.mu.Unlock()
+
+	if _, err := v.session.run(map[tf.Output]*tf.Tensor{v.value: x}, nil, v.assign); err != nil {
+		return fmt.Errorf("failed to assign variable: %w", err)
+	}
+
+	return nil
+}
+
+// AssignAdd adds value to variable in place. value needs to match
+// variable shape and data type needs to be numeric
+func (v *Variable[T]) AssignAdd(value *Tensor[T]) error {
+	switch any(*new(T)).(type) {
+	case string, bool:
+		return fmt.Errorf("assign add is not supported for data type %T", *new(T))
+	}
+
+	x, err := v.marshalValue(value)
+	if err != nil {
+		return err
+	}
+
+	v.session.mu.Lock()
+	defer v.session.mu.Unlock()
+
+	// add op is created on first use and reuses value placeholder
+	if v.assignAdd == nil {
+		if err := v.session.extend("assignAdd", func(scope *op.Scope) {
+			v.assignAdd = op.AssignAddVariableOp(scope, v.handle, v.value)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := v.session.run(map[tf.Output]*tf.Tensor{v.value: x}, nil, v.assignAdd); err != nil {
+		return fmt.Errorf("failed to assign add variable: %w", err)
+	}
+
+	return nil
+}
+
+// marshalValue checks value shape against variable and marshals it
+func (v *Variable[T]) marshalValue(value *Tensor[T]) (*tf.Tensor, error) {
+	if value == nil {
+		return nil, fmt.Errorf("value can't be nil")
+	}
+
+	if !equal(value.shape, v.shape) {
+		return nil, fmt.Errorf("value of shape %v does not match variable of shape %v", value.shape, v.shape)
+	}
+
+	x, err := value.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tf tensor: %w", err)
+	}
+
+	return x, nil
+}
+
+// Snapshot reads values of variables in a single session run, so that
+// values are consistent with each other. All variables need to belong
+// to the same session
+func Snapshot[T PrimitiveTypes](variables ...*Variable[T]) ([]*Tensor[T], error) {
+	if len(variables) == 0 {
+		return nil, nil
+	}
+
+	session, err := sessionOf(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	fetches := make([]tf.Output, len(variables))
+	for i, variable := range variables {
+		fetches[i] = variable.read
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	out, err := session.run(nil, fetches)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*Tensor[T], len(out))
+	for i := range out {
+		if outputs[i], err = fromTfTensor[T](out[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return outputs, nil
+}
+
+// sessionOf returns session of variables, which need to belong to the
+// same session
+func sessionOf[T PrimitiveTypes](variables []*Variable[T]) (*Session, error) {
+	var session *Session
+	for i, variable := range variables {
+		if variable == nil {
+			return nil, fmt.Errorf("variable %d can't be nil", i)
+		}
+
+		if session == nil {
+			session = variable.session
+		}
+
+		if variable.session != session {
+			return nil, fmt.Errorf("variable %d belongs to a different session", i)
+		}
+	}
+
+	return session, nil
+}
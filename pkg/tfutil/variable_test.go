@@ -0,0 +1,173 @@
+package tfutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/wamuir/graft/tensorflow/op"
+)
+
+func TestVariable(t *testing.T) {
+	session, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err := session.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	initial, err := NewTensor([]float64{1, 2, 3, 4}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variable, err := NewVariable(session, initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := variable.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(value.value, initial.value) || !equal(value.shape, []int{2, 2}) {
+		t.Fatal("variable value does not match initial value")
+	}
+
+	delta, err := NewTensor([]float64{10, 20, 30, 40}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// value accumulates across runs
+	for i := 0; i < 2; i++ {
+		if err := variable.AssignAdd(delta); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if value, err = variable.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(value.value, []float64{21, 42, 63, 84}) {
+		t.Fatal("variable value does not match expected value after assign add")
+	}
+
+	if err := variable.Assign(delta); err != nil {
+		t.Fatal(err)
+	}
+
+	seven, err := NewTensor([]int64{7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter, err := NewVariable(session, seven)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Snapshot(variable, variable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != 2 || !equal(values[0].value, delta.value) || !equal(values[1].value, delta.value) {
+		t.Fatal("snapshot does not match assigned value")
+	}
+
+	count, err := counter.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(count.value, []int64{7}) {
+		t.Fatal("counter value does not match initial value")
+	}
+
+	wrongShape, err := NewTensor([]float64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := variable.Assign(wrongShape); err == nil {
+		t.Fatal("expected assign to fail for value of mismatched shape")
+	}
+}
+
+func TestVariableClosedSession(t *testing.T) {
+	session, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hello, err := NewTensor([]string{"hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variable, err := NewVariable(session, hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := variable.AssignAdd(hello); err == nil {
+		t.Fatal("expected assign add to fail for string variable")
+	}
+
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := variable.Read(); err == nil {
+		t.Fatal("expected read to fail after session is closed")
+	}
+}
+
+func TestVariableFailedExtension(t *testing.T) {
+	session, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(session *Session) {
+		if err := session.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}(session)
+
+	if err := session.extend("failing", func(scope *op.Scope) {
+		scope.UpdateErr("failing", fmt.Errorf("invalid op"))
+	}); err == nil {
+		t.Fatal("expected extension to fail")
+	}
+
+	// later extensions are not affected by a failed one
+	x, err := NewTensor([]float64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variable, err := NewVariable(session, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := variable.AssignAdd(x); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := variable.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !equal(output.value, []float64{2, 4}) {
+		t.Fatalf("expected [2 4], got %v", output.value)
+	}
+}